@@ -1,7 +1,10 @@
 package main
 
 import (
+	"time"
+
 	"github.com/gratonos/gxlog"
+	"github.com/gratonos/gxlog/iface"
 	"github.com/gratonos/gxlog/logger"
 )
 
@@ -10,6 +13,7 @@ var log = gxlog.Logger()
 func main() {
 	testAdditional()
 	testDynamicContext()
+	testTypedContext()
 }
 
 func testAdditional() {
@@ -41,3 +45,10 @@ func testDynamicContext() {
 	clog.Info("dynamic one")
 	clog.Info("dynamic two")
 }
+
+func testTypedContext() {
+	log := log.WithContexts(iface.String("user", "gratonos"))
+	log.Infow("typed contexts", iface.Int("count", 3), iface.Bool("ok", true),
+		iface.Duration("cost", 20*time.Millisecond),
+		iface.Object("request", iface.String("path", "/index"), iface.Int("status", 200)))
+}
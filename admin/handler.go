@@ -0,0 +1,188 @@
+// Package admin implements an http.Handler to inspect and change a Logger at runtime.
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gratonos/gxlog/iface"
+	"github.com/gratonos/gxlog/logger"
+)
+
+// A HeaderFormatter is a Formatter with a header, e.g. *text.Formatter.
+type HeaderFormatter interface {
+	Header() string
+	SetHeader(header string)
+}
+
+type Config struct {
+	// Auth is called for each request if it is NOT nil. The request is
+	// rejected with 403 Forbidden if it returns false.
+	Auth func(req *http.Request) bool
+}
+
+// A Handler serves the state of a Logger as JSON with the method GET, e.g.
+//
+//	{"Level":"info","VModule":"","Slots":[{"Index":0,"Level":"trace",
+//	"Writer":"*file.Writer","Formatter":"*text.Formatter","Header":"..."}]}
+//
+// With the method PUT, it changes the Logger with the same JSON format, where
+// all the fields are optional and the read-only Writer and Formatter of slots
+// are ignored, thus a served state may be sent back as a whole. The Index of
+// a slot is required. The request is rejected as a whole if any
+// field is invalid. The new state is served if the request succeeds.
+type Handler struct {
+	logger *logger.Logger
+	auth   func(req *http.Request) bool
+}
+
+type slotState struct {
+	Index     int
+	Level     string
+	Writer    string
+	Formatter string
+	Header    string `json:",omitempty"`
+}
+
+type state struct {
+	Level   string
+	VModule string
+	Slots   []slotState
+}
+
+type slotUpdate struct {
+	Index     *int
+	Level     *string
+	Writer    *string // read-only, ignored
+	Formatter *string // read-only, ignored
+	Header    *string
+}
+
+type update struct {
+	Level   *string
+	VModule *string
+	Slots   []slotUpdate
+}
+
+func New(logger *logger.Logger, config Config) *Handler {
+	return &Handler{
+		logger: logger,
+		auth:   config.Auth,
+	}
+}
+
+func (this *Handler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	if this.auth != nil && !this.auth(req) {
+		http.Error(resp, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPut:
+		var upd update
+		decoder := json.NewDecoder(req.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&upd); err != nil {
+			http.Error(resp, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err := this.apply(&upd); err != nil {
+			http.Error(resp, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		resp.Header().Set("Allow", "GET, HEAD, PUT")
+		http.Error(resp, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	bs, err := json.Marshal(this.state())
+	if err != nil {
+		http.Error(resp, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp.Header().Set("Content-Type", "application/json")
+	_, _ = resp.Write(append(bs, '\n'))
+}
+
+func (this *Handler) state() *state {
+	st := &state{
+		Level:   this.logger.Level().String(),
+		VModule: this.logger.VModule(),
+		Slots:   make([]slotState, logger.MaxSlot),
+	}
+	for i := range st.Slots {
+		slot := this.logger.Slot(logger.SlotIndex(i))
+		st.Slots[i] = slotState{
+			Index:     i,
+			Level:     slot.Level.String(),
+			Writer:    fmt.Sprintf("%T", slot.Writer),
+			Formatter: fmt.Sprintf("%T", slot.Formatter),
+		}
+		if formatter, ok := slot.Formatter.(HeaderFormatter); ok {
+			st.Slots[i].Header = formatter.Header()
+		}
+	}
+	return st
+}
+
+// apply validates all the fields before changing anything.
+func (this *Handler) apply(upd *update) error {
+	var actions []func()
+
+	if upd.Level != nil {
+		level, err := parseLevel(*upd.Level)
+		if err != nil {
+			return err
+		}
+		actions = append(actions, func() { this.logger.SetLevel(level) })
+	}
+
+	for _, slot := range upd.Slots {
+		if slot.Index == nil {
+			return errors.New("slot index is missing")
+		}
+		if *slot.Index < 0 || *slot.Index >= logger.MaxSlot {
+			return fmt.Errorf("invalid slot index: %d", *slot.Index)
+		}
+		index := logger.SlotIndex(*slot.Index)
+
+		if slot.Level != nil {
+			level, err := parseLevel(*slot.Level)
+			if err != nil {
+				return err
+			}
+			actions = append(actions, func() { this.logger.SetSlotLevel(index, level) })
+		}
+		if slot.Header != nil {
+			formatter, ok := this.logger.SlotFormatter(index).(HeaderFormatter)
+			if !ok {
+				return fmt.Errorf("the formatter of slot %d has no header", index)
+			}
+			header := *slot.Header
+			actions = append(actions, func() { formatter.SetHeader(header) })
+		}
+	}
+
+	// SetVModule validates the spec, thus it is applied after the others are validated
+	if upd.VModule != nil {
+		if err := this.logger.SetVModule(*upd.VModule); err != nil {
+			return err
+		}
+	}
+	for _, action := range actions {
+		action()
+	}
+	return nil
+}
+
+func parseLevel(name string) (iface.Level, error) {
+	level, err := iface.ParseLevel(name)
+	if err != nil {
+		return 0, fmt.Errorf("invalid level: %q", name)
+	}
+	return level, nil
+}
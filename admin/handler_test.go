@@ -0,0 +1,143 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gratonos/gxlog/admin"
+	"github.com/gratonos/gxlog/formatter/text"
+	"github.com/gratonos/gxlog/iface"
+	"github.com/gratonos/gxlog/logger"
+)
+
+type slotState struct {
+	Index     int
+	Level     string
+	Writer    string
+	Formatter string
+	Header    string
+}
+
+type state struct {
+	Level   string
+	VModule string
+	Slots   []slotState
+}
+
+func TestGet(t *testing.T) {
+	log, _ := newLogger()
+	handler := admin.New(log, admin.Config{})
+
+	st := serve(t, handler, http.MethodGet, "", http.StatusOK)
+	if st.Level != "info" || len(st.Slots) != logger.MaxSlot {
+		t.Fatalf("TestGet: unexpected state: %+v", st)
+	}
+	slot := st.Slots[0]
+	if slot.Level != "trace" || slot.Formatter != "*text.Formatter" || slot.Header != "{{msg}}" {
+		t.Errorf("TestGet: unexpected slot: %+v", slot)
+	}
+}
+
+func TestPut(t *testing.T) {
+	log, formatter := newLogger()
+	handler := admin.New(log, admin.Config{})
+
+	body := `{"Level":"debug","VModule":"db/*=trace",` +
+		`"Slots":[{"Index":0,"Level":"warn","Header":"{{level}} {{msg}}"}]}`
+	st := serve(t, handler, http.MethodPut, body, http.StatusOK)
+	if st.Level != "debug" || st.VModule != "db/*=trace" || st.Slots[0].Level != "warn" {
+		t.Errorf("TestPut: unexpected state: %+v", st)
+	}
+	if log.Level() != iface.Debug || log.SlotLevel(logger.Slot0) != iface.Warn {
+		t.Error("TestPut: levels are not changed")
+	}
+	if formatter.Header() != "{{level}} {{msg}}" {
+		t.Errorf("TestPut: header is not changed: %q", formatter.Header())
+	}
+
+	invalids := []string{
+		`{"Level":"verbose"}`,
+		`{"VModule":"[=info"}`,
+		`{"Slots":[{"Index":8,"Level":"info"}]}`,
+		`{"Slots":[{"Index":1,"Header":"{{msg}}"}]}`,
+		`{"Slots":[{"Level":"info"}]}`,
+		`{"Unknown":1}`,
+		`{"Level":"error","Slots":[{"Index":0,"Level":"verbose"}]}`,
+	}
+	for _, body := range invalids {
+		serve(t, handler, http.MethodPut, body, http.StatusBadRequest)
+	}
+	if log.Level() != iface.Debug {
+		t.Error("TestPut: an invalid request is partially applied")
+	}
+}
+
+func TestGetAndPut(t *testing.T) {
+	log, _ := newLogger()
+	handler := admin.New(log, admin.Config{})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("TestGetAndPut: expect %d, got %d", http.StatusOK, resp.Code)
+	}
+	body := resp.Body.String()
+
+	// the served state is sent back as a whole
+	req = httptest.NewRequest(http.MethodPut, "/", strings.NewReader(body))
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("TestGetAndPut: expect %d, got %d: %s", http.StatusOK, resp.Code, resp.Body)
+	}
+	if resp.Body.String() != body {
+		t.Errorf("TestGetAndPut: the state is changed:\n%s\nexpect:\n%s", resp.Body, body)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	log, _ := newLogger()
+	handler := admin.New(log, admin.Config{
+		Auth: func(req *http.Request) bool {
+			return req.Header.Get("Authorization") == "Bearer secret"
+		},
+	})
+
+	serve(t, handler, http.MethodGet, "", http.StatusForbidden)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Errorf("TestAuth: expect %d, got %d", http.StatusOK, resp.Code)
+	}
+}
+
+func serve(t *testing.T, handler http.Handler, method, body string, code int) *state {
+	req := httptest.NewRequest(method, "/", strings.NewReader(body))
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	if resp.Code != code {
+		t.Fatalf("%s %s: expect %d, got %d: %s", method, body, code, resp.Code, resp.Body)
+	}
+	if code != http.StatusOK {
+		return nil
+	}
+	st := new(state)
+	if err := json.Unmarshal(resp.Body.Bytes(), st); err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+func newLogger() (*logger.Logger, *text.Formatter) {
+	formatter := text.New(text.Config{Header: "{{msg}}"})
+	log := logger.New(logger.Config{Level: iface.Info})
+	log.SetSlot(logger.Slot0, logger.Slot{Formatter: formatter})
+	return log, formatter
+}
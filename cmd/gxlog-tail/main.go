@@ -0,0 +1,183 @@
+// Command gxlog-tail tails the logs of usock writers.
+//
+// Usage:
+//
+//	gxlog-tail [flags] [label=]path ...
+//
+// It connects to every unix domain socket of the paths, subscribes to the
+// logs by the flags, and reconnects if a socket is closed, e.g. when the
+// process restarts. The logs of json formatters are pretty-printed like the
+// text formatter, and the others are printed as they are. The logs of
+// multiple sockets are merged by lines and labeled by the labels, which
+// default to the base names of the paths.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gratonos/gxlog/writer/usock"
+)
+
+// the colors of labels in turn
+var labelColors = []int{36, 34, 35, 33} // cyan, blue, magenta, yellow
+
+type contextsFlag map[string]string
+
+func (self contextsFlag) String() string {
+	pairs := make([]string, 0, len(self))
+	for key, value := range self {
+		pairs = append(pairs, key+"="+value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (self contextsFlag) Set(pair string) error {
+	i := strings.IndexByte(pair, '=')
+	if i <= 0 {
+		return errors.New("the context MUST be key=value")
+	}
+	self[pair[:i]] = pair[i+1:]
+	return nil
+}
+
+func main() {
+	var sub usock.Subscription
+	contexts := make(contextsFlag)
+	flag.StringVar(&sub.Level, "level", "", "the min `level`, e.g. info")
+	flag.StringVar(&sub.Pkg, "pkg", "", "the `pattern` of packages, e.g. \"github.com/org/*\"")
+	flag.StringVar(&sub.File, "file", "", "the `pattern` of files, e.g. \"main.go\"")
+	flag.Var(contexts, "ctx", "the `key=value` of a context to match, which is repeatable")
+	flag.StringVar(&sub.Msg, "msg", "", "the `regexp` of messages")
+	flag.IntVar(&sub.Replay, "replay", 0, "replay the latest `n` logs if the writers keep them")
+	color := flag.String("color", "auto", "colorize the logs: auto, always or never")
+	raw := flag.Bool("raw", false, "print the logs as they are")
+	labels := flag.String("labels", "auto", "label the logs by sockets: auto, always or never")
+	reconnect := flag.Duration("reconnect", time.Second,
+		"the `interval` to reconnect, or exit when all sockets are closed if it is 0")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [label=]path ...\n",
+			filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if len(contexts) > 0 {
+		sub.Contexts = contexts
+	}
+	subscription, err := json.Marshal(&sub)
+	if err != nil {
+		fatal(err)
+	}
+	subscription = append(subscription, '\n')
+
+	coloring, err := parseSwitch("color", *color, isTerminal(os.Stdout))
+	if err != nil {
+		fatal(err)
+	}
+	labeling, err := parseSwitch("labels", *labels, flag.NArg() > 1)
+	if err != nil {
+		fatal(err)
+	}
+
+	lines := make(chan line, 1024)
+	var wg sync.WaitGroup
+	for i, arg := range flag.Args() {
+		src := &source{
+			path:         arg,
+			label:        filepath.Base(arg),
+			color:        labelColors[i%len(labelColors)],
+			subscription: subscription,
+			reconnect:    *reconnect,
+		}
+		if j := strings.IndexByte(arg, '='); j > 0 {
+			src.label, src.path = arg[:j], arg[j+1:]
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			src.Run(lines)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(lines)
+	}()
+
+	printLines(lines, &printer{coloring: coloring, raw: *raw}, labeling)
+}
+
+// printLines prints the lines until the channel is closed, and flushes the
+// output whenever there are no more lines for now.
+func printLines(lines <-chan line, printer *printer, labeling bool) {
+	writer := bufio.NewWriter(os.Stdout)
+	var buf []byte
+	for l := range lines {
+		for {
+			buf = buf[:0]
+			if labeling {
+				if printer.coloring {
+					buf = appendColorSeq(buf, l.source.color)
+				}
+				buf = append(buf, '[')
+				buf = append(buf, l.source.label...)
+				buf = append(buf, "] "...)
+				if printer.coloring {
+					buf = append(buf, resetSeq...)
+				}
+			}
+			buf = printer.Render(buf, l.bs)
+			buf = append(buf, '\n')
+			if _, err := writer.Write(buf); err != nil {
+				fatal(err)
+			}
+
+			var ok bool
+			select {
+			case l, ok = <-lines:
+			default:
+			}
+			if !ok {
+				break
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			fatal(err)
+		}
+	}
+}
+
+func parseSwitch(name, value string, auto bool) (bool, error) {
+	switch value {
+	case "auto":
+		return auto, nil
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid -%s: %q", name, value)
+	}
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "gxlog-tail: %v\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gratonos/gxlog/iface"
+)
+
+const (
+	timeLayout = "2006-01-02 15:04:05.000000"
+	resetSeq   = "\033[0m"
+)
+
+var levelNames = []string{
+	iface.Trace: "TRACE",
+	iface.Debug: "DEBUG",
+	iface.Info:  "INFO ",
+	iface.Warn:  "WARN ",
+	iface.Error: "ERROR",
+	iface.Fatal: "FATAL",
+}
+
+// the same as the defaults of the text formatter
+var levelColors = []int{
+	iface.Trace: 32, // green
+	iface.Debug: 32,
+	iface.Info:  32,
+	iface.Warn:  33, // yellow
+	iface.Error: 31, // red
+	iface.Fatal: 31,
+}
+
+const markColor = 35 // magenta
+
+// A printer renders the logs of json formatters like the text formatter
+// with the StdHeader. Other logs are printed as they are.
+type printer struct {
+	coloring bool
+	raw      bool
+}
+
+// Render renders a line without the trailing newline.
+func (this *printer) Render(buf []byte, line []byte) []byte {
+	if this.raw {
+		return append(buf, line...)
+	}
+	fields, ok := parseFields(line)
+	if !ok {
+		return append(buf, line...)
+	}
+
+	level := fields.Level()
+	var color int
+	if this.coloring {
+		if fields.Bool("mark") {
+			color = markColor
+		} else {
+			color = levelColors[level]
+		}
+		buf = appendColorSeq(buf, color)
+	}
+
+	buf = fields.AppendTime(buf)
+	buf = append(buf, ' ')
+	buf = append(buf, levelNames[level]...)
+	buf = append(buf, ' ')
+	file := fields.Str("file")
+	if i := strings.LastIndexByte(file, '/'); i >= 0 {
+		file = file[i+1:]
+	}
+	buf = append(buf, file...)
+	buf = append(buf, ':')
+	buf = append(buf, fields.Raw("line")...)
+	buf = append(buf, ' ')
+	buf = append(buf, fields.Str("pkg")...)
+	buf = append(buf, '.')
+	buf = append(buf, fields.Str("func")...)
+	buf = append(buf, ' ')
+	buf = append(buf, fields.Str("prefix")...)
+	buf = append(buf, '[')
+	buf = fields.AppendContexts(buf)
+	buf = append(buf, "] "...)
+	buf = append(buf, fields.Str("msg")...)
+	if stack := strings.TrimSuffix(fields.Str("stack"), "\n"); stack != "" {
+		buf = append(buf, '\n')
+		buf = append(buf, stack...)
+	}
+
+	if this.coloring {
+		buf = append(buf, resetSeq...)
+	}
+	return buf
+}
+
+// fields are the fields of a log of json formatters, whose keys are in
+// lower case.
+type fields map[string]json.RawMessage
+
+func parseFields(line []byte) (fields, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return nil, false
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return nil, false
+	}
+	this := make(fields, len(raw))
+	for key, value := range raw {
+		this[strings.ToLower(key)] = value
+	}
+	if _, ok := this["msg"]; !ok {
+		return nil, false
+	}
+	return this, true
+}
+
+func (this fields) Raw(key string) string {
+	return string(this[key])
+}
+
+func (this fields) Str(key string) string {
+	var str string
+	if err := json.Unmarshal(this[key], &str); err != nil {
+		return this.Raw(key)
+	}
+	return str
+}
+
+func (this fields) Bool(key string) bool {
+	var ok bool
+	_ = json.Unmarshal(this[key], &ok)
+	return ok
+}
+
+// Level accepts both the numbers and the names of levels. It falls back to
+// Info if the level is missing or invalid.
+func (this fields) Level() iface.Level {
+	level := iface.Info
+	if n, err := strconv.Atoi(this.Raw("level")); err == nil {
+		level = iface.Level(n)
+	} else if parsed, err := iface.ParseLevel(this.Str("level")); err == nil {
+		level = parsed
+	}
+	if level < iface.Trace || level > iface.Fatal {
+		level = iface.Info
+	}
+	return level
+}
+
+func (this fields) AppendTime(buf []byte) []byte {
+	str := this.Str("time")
+	t, err := time.Parse(time.RFC3339Nano, str)
+	if err != nil {
+		return append(buf, str...)
+	}
+	return t.AppendFormat(buf, timeLayout)
+}
+
+// AppendContexts appends the contexts in pairs, e.g. (k1: v1) (k2: v2).
+// The contexts are either an array of {"Key": k, "Value": v} or an object.
+func (this fields) AppendContexts(buf []byte) []byte {
+	raw := this["contexts"]
+	var array []struct {
+		Key   string
+		Value json.RawMessage
+	}
+	if err := json.Unmarshal(raw, &array); err == nil {
+		for i, context := range array {
+			buf = appendPair(buf, i, context.Key, context.Value)
+		}
+		return buf
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &object); err == nil {
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for i, key := range keys {
+			buf = appendPair(buf, i, key, object[key])
+		}
+	}
+	return buf
+}
+
+func appendPair(buf []byte, i int, key string, value json.RawMessage) []byte {
+	if i > 0 {
+		buf = append(buf, ' ')
+	}
+	buf = append(buf, '(')
+	buf = append(buf, key...)
+	buf = append(buf, ": "...)
+	var str string
+	if err := json.Unmarshal(value, &str); err == nil {
+		if str == "" || strings.ContainsAny(str, " \"\t\r\n") {
+			buf = strconv.AppendQuote(buf, str)
+		} else {
+			buf = append(buf, str...)
+		}
+	} else {
+		buf = append(buf, value...)
+	}
+	return append(buf, ')')
+}
+
+func appendColorSeq(buf []byte, color int) []byte {
+	return append(buf, fmt.Sprintf("\033[%dm", color)...)
+}
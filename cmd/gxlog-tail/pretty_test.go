@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gratonos/gxlog/formatter/json"
+	"github.com/gratonos/gxlog/iface"
+)
+
+func TestRender(t *testing.T) {
+	record := &iface.Record{
+		Time:     time.Date(2026, 10, 18, 10, 15, 0, 123456000, time.UTC),
+		Level:    iface.Warn,
+		File:     "/src/app/main.go",
+		Line:     42,
+		Pkg:      "main",
+		Func:     "run",
+		Msg:      "hello",
+		Prefix:   "** ",
+		Contexts: []iface.Context{iface.String("user", "alice bob"), iface.Int("n", 1)},
+	}
+	line := json.New(json.Config{}).Format(record)
+	line = line[:len(line)-1]
+
+	tests := []struct {
+		printer printer
+		line    string
+		want    string
+	}{
+		{printer{}, string(line),
+			`2026-10-18 10:15:00.123456 WARN  main.go:42 main.run ** [(user: "alice bob") (n: 1)] hello`},
+		{printer{coloring: true}, string(line),
+			"\033[33m2026-10-18 10:15:00.123456 WARN  main.go:42 main.run ** " +
+				`[(user: "alice bob") (n: 1)] hello` + "\033[0m"},
+		{printer{raw: true}, string(line), string(line)},
+		{printer{}, `{"time":"t","level":"error","msg":"m","contexts":{"b":2,"a":"x"},"stack":"s\n"}`,
+			"t ERROR : . [(a: x) (b: 2)] m\ns"},
+		{printer{}, `{"time":"t","msg":"m"}`, "t INFO  : . [] m"},
+		{printer{}, `{"time":"t","level":"verbose","msg":"m"}`, "t INFO  : . [] m"},
+		{printer{}, `{"time":"t","level":0,"msg":"m"}`, "t TRACE : . [] m"},
+		{printer{}, "plain text", "plain text"},
+	}
+	for _, test := range tests {
+		if got := string(test.printer.Render(nil, []byte(test.line))); got != test.want {
+			t.Errorf("TestRender: want %q, got %q", test.want, got)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+type line struct {
+	source *source
+	bs     []byte
+}
+
+// A source reads the logs from a usock writer.
+type source struct {
+	label        string
+	path         string
+	color        int
+	subscription []byte
+	reconnect    time.Duration // NOT to reconnect if it is NOT positive
+}
+
+// Run sends the lines to the channel until the source is disconnected and
+// NOT to reconnect.
+func (this *source) Run(lines chan<- line) {
+	connected := true // NOT to report the first failure repeatedly
+	for {
+		err := this.read(lines)
+		if err != nil && connected {
+			fmt.Fprintf(os.Stderr, "gxlog-tail: %s: %v\n", this.label, err)
+		}
+		connected = err == nil
+		if this.reconnect <= 0 {
+			return
+		}
+		time.Sleep(this.reconnect)
+	}
+}
+
+// read returns nil when the connection is closed by the writer.
+func (this *source) read(lines chan<- line) error {
+	conn, err := net.Dial("unix", this.path)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(this.subscription); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "gxlog-tail: %s: connected\n", this.label)
+
+	reader := bufio.NewReader(conn)
+	for {
+		bs, err := reader.ReadBytes('\n')
+		if len(bs) > 0 {
+			if bs[len(bs)-1] == '\n' {
+				bs = bs[:len(bs)-1]
+			}
+			lines <- line{source: this, bs: bs}
+		}
+		if err == io.EOF {
+			fmt.Fprintf(os.Stderr, "gxlog-tail: %s: disconnected\n", this.label)
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,515 @@
+package config
+
+import (
+	"compress/gzip"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gratonos/gxlog/formatter/cbor"
+	"github.com/gratonos/gxlog/formatter/ecs"
+	"github.com/gratonos/gxlog/formatter/frame"
+	"github.com/gratonos/gxlog/formatter/json"
+	"github.com/gratonos/gxlog/formatter/logfmt"
+	"github.com/gratonos/gxlog/formatter/msgpack"
+	"github.com/gratonos/gxlog/formatter/otel"
+	"github.com/gratonos/gxlog/formatter/text"
+	"github.com/gratonos/gxlog/iface"
+	"github.com/gratonos/gxlog/logger"
+	"github.com/gratonos/gxlog/writer"
+	"github.com/gratonos/gxlog/writer/file"
+	"github.com/gratonos/gxlog/writer/syslog"
+	"github.com/gratonos/gxlog/writer/usock"
+)
+
+var headers = map[string]string{
+	"full":    text.FullHeader,
+	"std":     text.StdHeader,
+	"compact": text.CompactHeader,
+	"syslog":  text.SyslogHeader,
+}
+
+var colors = map[string]text.Color{
+	"black":   text.Black,
+	"red":     text.Red,
+	"green":   text.Green,
+	"yellow":  text.Yellow,
+	"blue":    text.Blue,
+	"magenta": text.Magenta,
+	"cyan":    text.Cyan,
+	"white":   text.White,
+}
+
+var jsonFields = map[string]json.Field{
+	"time":     json.FieldTime,
+	"level":    json.FieldLevel,
+	"file":     json.FieldFile,
+	"line":     json.FieldLine,
+	"pkg":      json.FieldPkg,
+	"func":     json.FieldFunc,
+	"msg":      json.FieldMsg,
+	"stack":    json.FieldStack,
+	"prefix":   json.FieldPrefix,
+	"contexts": json.FieldContexts,
+	"mark":     json.FieldMark,
+}
+
+var facilities = map[string]syslog.Facility{
+	"kern":     syslog.FacKern,
+	"user":     syslog.FacUser,
+	"mail":     syslog.FacMail,
+	"daemon":   syslog.FacDaemon,
+	"auth":     syslog.FacAuth,
+	"syslog":   syslog.FacSyslog,
+	"lpr":      syslog.FacLPR,
+	"news":     syslog.FacNews,
+	"uucp":     syslog.FacUUCP,
+	"cron":     syslog.FacCron,
+	"authpriv": syslog.FacAuthPriv,
+	"ftp":      syslog.FacFTP,
+}
+
+var errorHandlers = map[string]logger.ErrorHandler{
+	"":        logger.NullErrorHandler(),
+	"null":    logger.NullErrorHandler(),
+	"report":  logger.Report,
+	"details": logger.ReportDetails,
+}
+
+// Build returns a new Logger configured by the config.
+func Build(config *Config) (*logger.Logger, error) {
+	log := logger.New(logger.Config{})
+	if err := apply(log, config); err != nil {
+		_ = log.Close()
+		return nil, fmt.Errorf("config.Build: %v", err)
+	}
+	return log, nil
+}
+
+// Apply configures the Logger with the config. Slots NOT in the config are
+// reset. Nothing is changed if the config is invalid or any writer fails to
+// open. The writers replaced are NOT closed.
+func Apply(log *logger.Logger, config *Config) error {
+	if err := apply(log, config); err != nil {
+		return fmt.Errorf("config.Apply: %v", err)
+	}
+	return nil
+}
+
+func apply(log *logger.Logger, config *Config) error {
+	level, err := parseLevel("level", config.Level)
+	if err != nil {
+		return err
+	}
+	if len(config.Slots) > logger.MaxSlot {
+		return fmt.Errorf("slots: too many slots: %d > %d", len(config.Slots), logger.MaxSlot)
+	}
+
+	var slots []*logger.Slot
+	for i := range config.Slots {
+		slot, err := buildSlot(fmt.Sprintf("slots[%d]", i), &config.Slots[i], nil)
+		if err != nil {
+			closeSlots(slots)
+			return err
+		}
+		slots = append(slots, slot)
+	}
+	if err := log.Reconfigure(level, config.VModule, slots); err != nil {
+		closeSlots(slots)
+		return fmt.Errorf("vmodule: %v", err)
+	}
+	return nil
+}
+
+// buildSlot returns nil if the slot is unused. A writer is opened unless wt
+// is NOT nil.
+func buildSlot(path string, config *SlotConfig, wt iface.Writer) (*logger.Slot, error) {
+	level, err := parseLevel(path+".level", config.Level)
+	if err != nil {
+		return nil, err
+	}
+	handler, ok := errorHandlers[strings.ToLower(config.ErrorHandler)]
+	if !ok {
+		return nil, fmt.Errorf("%s.errorHandler: invalid error handler %q",
+			path, config.ErrorHandler)
+	}
+	formatter, err := buildFormatter(path+".formatter", &config.Formatter)
+	if err != nil {
+		return nil, err
+	}
+	formatter, err = buildFrame(path+".formatter.frame", config.Formatter.Frame, formatter)
+	if err != nil {
+		return nil, err
+	}
+	if config.Writer.Type == "" {
+		return nil, nil
+	}
+	if wt == nil {
+		if wt, err = buildWriter(path+".writer", &config.Writer, formatter); err != nil {
+			return nil, err
+		}
+	}
+	return &logger.Slot{
+		Formatter:    formatter,
+		Writer:       wt,
+		Level:        level,
+		ErrorHandler: handler,
+	}, nil
+}
+
+func buildFormatter(path string, config *FormatterConfig) (iface.Formatter, error) {
+	switch strings.ToLower(config.Type) {
+	case "", "text":
+		textConfig := text.Config{
+			Header:   config.Header,
+			Coloring: config.Coloring,
+		}
+		if header, ok := headers[strings.ToLower(config.Header)]; ok {
+			textConfig.Header = header
+		}
+		if len(config.Colors) > 0 {
+			textConfig.ColorMap = make(map[iface.Level]text.Color, len(config.Colors))
+			for levelName, colorName := range config.Colors {
+				level, err := parseLevel(path+".colors", levelName)
+				if err != nil {
+					return nil, err
+				}
+				color, err := parseColor(path+".colors."+levelName, colorName)
+				if err != nil {
+					return nil, err
+				}
+				textConfig.ColorMap[level] = color
+			}
+		}
+		if config.MarkColor != "" {
+			color, err := parseColor(path+".markColor", config.MarkColor)
+			if err != nil {
+				return nil, err
+			}
+			textConfig.MarkColor = color
+		}
+		return text.New(textConfig), nil
+	case "json":
+		jsonConfig := json.Config{
+			FileSegs:     config.FileSegs,
+			PkgSegs:      config.PkgSegs,
+			FuncSegs:     config.FuncSegs,
+			OmitEmpty:    config.OmitEmpty,
+			LevelName:    config.LevelName,
+			TimeLayout:   config.TimeLayout,
+			FlatContexts: config.FlatContexts,
+		}
+		for i, name := range config.Fields {
+			field, err := parseJSONField(fmt.Sprintf("%s.fields[%d]", path, i), name)
+			if err != nil {
+				return nil, err
+			}
+			jsonConfig.Fields = append(jsonConfig.Fields, field)
+		}
+		if len(config.Keys) > 0 {
+			jsonConfig.Keys = make(map[json.Field]string, len(config.Keys))
+			for name, key := range config.Keys {
+				field, err := parseJSONField(path+".keys", name)
+				if err != nil {
+					return nil, err
+				}
+				jsonConfig.Keys[field] = key
+			}
+		}
+		return json.New(jsonConfig), nil
+	case "logfmt":
+		return logfmt.New(logfmt.Config{
+			FileSegs: config.FileSegs,
+			PkgSegs:  config.PkgSegs,
+			FuncSegs: config.FuncSegs,
+		}), nil
+	case "ecs":
+		return ecs.New(ecs.Config{
+			FileSegs:    config.FileSegs,
+			PkgSegs:     config.PkgSegs,
+			FuncSegs:    config.FuncSegs,
+			ContextsKey: config.ContextsKey,
+		}), nil
+	case "otel":
+		return otel.New(otel.Config{
+			FileSegs: config.FileSegs,
+			PkgSegs:  config.PkgSegs,
+			FuncSegs: config.FuncSegs,
+		}), nil
+	case "msgpack":
+		return msgpack.New(), nil
+	case "cbor":
+		return cbor.New(), nil
+	default:
+		return nil, fmt.Errorf("%s.type: invalid formatter type %q", path, config.Type)
+	}
+}
+
+func buildFrame(path, name string, formatter iface.Formatter) (iface.Formatter, error) {
+	switch strings.ToLower(name) {
+	case "":
+		return formatter, nil
+	case "varint":
+		return frame.New(frame.Config{Formatter: formatter, Length: frame.Varint}), nil
+	case "fixed32":
+		return frame.New(frame.Config{Formatter: formatter, Length: frame.Fixed32}), nil
+	default:
+		return nil, fmt.Errorf("%s: invalid frame %q", path, name)
+	}
+}
+
+// The formatter is used to frame the notices of a usock writer if it is a
+// frame.Formatter.
+func buildWriter(path string, config *WriterConfig, formatter iface.Formatter) (iface.Writer, error) {
+	switch strings.ToLower(config.Type) {
+	case "stderr":
+		return writer.Wrap(os.Stderr), nil
+	case "stdout":
+		return writer.Wrap(os.Stdout), nil
+	case "file":
+		return buildFileWriter(path, config)
+	case "syslog":
+		return buildSyslogWriter(path, config)
+	case "usock":
+		return buildUsockWriter(path, config, formatter)
+	default:
+		return nil, fmt.Errorf("%s.type: invalid writer type %q", path, config.Type)
+	}
+}
+
+func buildFileWriter(path string, config *WriterConfig) (iface.Writer, error) {
+	var err error
+	var period time.Duration
+	switch strings.ToLower(config.Period) {
+	case "":
+	case "hourly":
+		period = file.Hourly
+	case "daily":
+		period = file.Daily
+	case "weekly":
+		period = file.Weekly
+	default:
+		if period, err = parseDuration(path+".period", config.Period); err != nil {
+			return nil, err
+		}
+	}
+	var location *time.Location
+	if config.TimeZone != "" {
+		if location, err = time.LoadLocation(config.TimeZone); err != nil {
+			return nil, fmt.Errorf("%s.timeZone: %v", path, err)
+		}
+	}
+	maxAge, err := parseDuration(path+".maxAge", config.MaxAge)
+	if err != nil {
+		return nil, err
+	}
+	var compressor file.Compressor
+	switch strings.ToLower(config.Compression) {
+	case "":
+	case "gzip":
+		compressor = file.Gzip(gzip.DefaultCompression)
+	default:
+		return nil, fmt.Errorf("%s.compression: invalid compression %q",
+			path, config.Compression)
+	}
+	flushInterval, err := parseDuration(path+".flushInterval", config.FlushInterval)
+	if err != nil {
+		return nil, err
+	}
+	var flushLevel iface.Level
+	if config.FlushLevel != "" {
+		if flushLevel, err = parseLevel(path+".flushLevel", config.FlushLevel); err != nil {
+			return nil, err
+		}
+	}
+	var syncPolicy file.SyncPolicy
+	var syncInterval time.Duration
+	switch strings.ToLower(config.Sync) {
+	case "", "never":
+		syncPolicy = file.SyncNever
+	case "rotation":
+		syncPolicy = file.SyncOnRotation
+	case "always":
+		syncPolicy = file.SyncAlways
+	default:
+		if syncInterval, err = parseDuration(path+".sync", config.Sync); err != nil {
+			return nil, err
+		}
+		syncPolicy = file.SyncPeriodically
+	}
+
+	wt, err := file.Open(file.Config{
+		Dir:           config.Dir,
+		MaxFileSize:   config.MaxFileSize,
+		Pattern:       config.Pattern,
+		App:           config.App,
+		Period:        period,
+		Location:      location,
+		Symlink:       config.Symlink,
+		MaxAge:        maxAge,
+		MaxFiles:      config.MaxFiles,
+		MaxTotalSize:  config.MaxTotalSize,
+		Compressor:    compressor,
+		BufferSize:    config.BufferSize,
+		FlushInterval: flushInterval,
+		FlushLevel:    flushLevel,
+		SyncPolicy:    syncPolicy,
+		SyncInterval:  syncInterval,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return wt, nil
+}
+
+func buildSyslogWriter(path string, config *WriterConfig) (iface.Writer, error) {
+	facility, err := parseFacility(path+".facility", config.Facility)
+	if err != nil {
+		return nil, err
+	}
+	var format syslog.Format
+	switch strings.ToLower(config.Format) {
+	case "", "bsd":
+		format = syslog.FormatBSD
+	case "rfc5424":
+		format = syslog.FormatRFC5424
+	default:
+		return nil, fmt.Errorf("%s.format: invalid format %q", path, config.Format)
+	}
+	timeout, err := parseDuration(path+".timeout", config.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	minBackoff, err := parseDuration(path+".minBackoff", config.MinBackoff)
+	if err != nil {
+		return nil, err
+	}
+	maxBackoff, err := parseDuration(path+".maxBackoff", config.MaxBackoff)
+	if err != nil {
+		return nil, err
+	}
+
+	wt, err := syslog.Open(syslog.Config{
+		Tag:        config.Tag,
+		Facility:   facility,
+		Network:    config.Network,
+		Addr:       config.Addr,
+		Timeout:    timeout,
+		Format:     format,
+		Hostname:   config.Hostname,
+		MsgID:      config.MsgID,
+		SDID:       config.SDID,
+		MinBackoff: minBackoff,
+		MaxBackoff: maxBackoff,
+		SpillSize:  config.SpillSize,
+		SpillPath:  config.SpillPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return wt, nil
+}
+
+func buildUsockWriter(path string, config *WriterConfig, formatter iface.Formatter) (iface.Writer, error) {
+	if config.Path == "" {
+		return nil, fmt.Errorf("%s.path: empty path", path)
+	}
+	var slowPolicy usock.SlowPolicy
+	switch strings.ToLower(config.SlowPolicy) {
+	case "", "drop":
+		slowPolicy = usock.DropSlow
+	case "disconnect":
+		slowPolicy = usock.DisconnectSlow
+	default:
+		return nil, fmt.Errorf("%s.slowPolicy: invalid slow policy %q", path, config.SlowPolicy)
+	}
+	writeTimeout, err := parseDuration(path+".writeTimeout", config.WriteTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	usockConfig := usock.Config{
+		Path:         config.Path,
+		MaxClients:   config.MaxClients,
+		QueueSize:    config.QueueSize,
+		SlowPolicy:   slowPolicy,
+		WriteTimeout: writeTimeout,
+		ReplaySize:   config.ReplaySize,
+	}
+	if fmtr, ok := formatter.(*frame.Formatter); ok {
+		usockConfig.Frame = fmtr.Frame
+	}
+	wt, err := usock.OpenConfig(usockConfig)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return wt, nil
+}
+
+// closeSlots closes the writers opened by buildSlot.
+func closeSlots(slots []*logger.Slot) {
+	for _, slot := range slots {
+		if slot != nil {
+			_ = closeWriter(slot.Writer)
+		}
+	}
+}
+
+func closeWriter(wt iface.Writer) error {
+	if closer, ok := wt.(iface.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func parseLevel(path, name string) (iface.Level, error) {
+	if name == "" {
+		return iface.Trace, nil
+	}
+	level, err := iface.ParseLevel(name)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid level %q", path, name)
+	}
+	return level, nil
+}
+
+// parseDuration returns 0 if the text is empty.
+func parseDuration(path, text string) (time.Duration, error) {
+	if text == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(text)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("%s: invalid duration %q", path, text)
+	}
+	return duration, nil
+}
+
+func parseColor(path, name string) (text.Color, error) {
+	color, ok := colors[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("%s: invalid color %q", path, name)
+	}
+	return color, nil
+}
+
+func parseJSONField(path, name string) (json.Field, error) {
+	field, ok := jsonFields[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("%s: invalid field %q", path, name)
+	}
+	return field, nil
+}
+
+// parseFacility returns syslog.FacUser if the name is empty.
+func parseFacility(path, name string) (syslog.Facility, error) {
+	if name == "" {
+		return syslog.FacUser, nil
+	}
+	facility, ok := facilities[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("%s: invalid facility %q", path, name)
+	}
+	return facility, nil
+}
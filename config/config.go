@@ -0,0 +1,125 @@
+// Package config implements declarative configuration of a Logger.
+//
+// A configuration in JSON looks like:
+//
+//	{
+//	  "level": "info",
+//	  "vmodule": "db/*=trace",
+//	  "slots": [
+//	    {"formatter": {"type": "text", "header": "std", "coloring": true},
+//	     "writer": {"type": "stderr"}},
+//	    {"level": "warn", "errorHandler": "report",
+//	     "formatter": {"type": "json", "fileSegs": 1},
+//	     "writer": {"type": "file", "dir": "/var/log/app", "maxFileSize": 10485760}}
+//	  ]
+//	}
+//
+// The n-th element of slots configures the Slot n. Keys are case-insensitive
+// and unknown keys are rejected.
+package config
+
+type Config struct {
+	Level   string       `config:"level"`
+	VModule string       `config:"vmodule"`
+	Slots   []SlotConfig `config:"slots"`
+}
+
+type SlotConfig struct {
+	Level     string          `config:"level"`
+	Formatter FormatterConfig `config:"formatter"`
+	Writer    WriterConfig    `config:"writer"`
+	// "null" (default), "report" or "details"
+	ErrorHandler string `config:"errorHandler"`
+}
+
+type FormatterConfig struct {
+	// "text" (default), "json", "logfmt", "ecs", "otel", "msgpack" or "cbor"
+	Type string `config:"type"`
+	// "varint" or "fixed32" to wrap the formatter with length-prefixed
+	// frames, see package formatter/frame. The default is no frame. The
+	// notices of a usock writer are framed as well.
+	Frame string `config:"frame"`
+
+	// text only. The header may also be one of "full", "std", "compact"
+	// and "syslog", which represent the predefined headers.
+	Header   string `config:"header"`
+	Coloring bool   `config:"coloring"`
+	// level name -> color name, e.g. {"warn": "yellow"}
+	Colors    map[string]string `config:"colors"`
+	MarkColor string            `config:"markColor"`
+
+	// json, logfmt, ecs and otel only
+	FileSegs int `config:"fileSegs"`
+	PkgSegs  int `config:"pkgSegs"`
+	FuncSegs int `config:"funcSegs"`
+
+	// ecs only. See ecs.Config.
+	ContextsKey string `config:"contextsKey"`
+
+	// json only. The fields are the field names in order, e.g. ["time",
+	// "level", "msg"]. The keys map field names to keys, e.g. {"time": "ts"}.
+	// The timeLayout may also be "unixmilli" or "unixnano". See json.Config
+	// for the others.
+	Fields       []string          `config:"fields"`
+	Keys         map[string]string `config:"keys"`
+	OmitEmpty    bool              `config:"omitEmpty"`
+	LevelName    bool              `config:"levelName"`
+	TimeLayout   string            `config:"timeLayout"`
+	FlatContexts bool              `config:"flatContexts"`
+}
+
+type WriterConfig struct {
+	// "stderr", "stdout", "file", "syslog" or "usock". The slot is unused if
+	// the type is empty.
+	Type string `config:"type"`
+
+	// file only. The period is "hourly", "daily", "weekly" or a duration,
+	// e.g. "30m". The timeZone is a name of the IANA Time Zone database, e.g.
+	// "UTC". The maxAge is a duration, e.g. "72h". The compression of rotated
+	// files is "gzip" or empty. The flushInterval is a duration. The sync is
+	// "never" (default), "rotation", "always" or a duration to sync
+	// periodically. See file.Config for the others.
+	Dir           string `config:"dir"`
+	MaxFileSize   int64  `config:"maxFileSize"`
+	Pattern       string `config:"pattern"`
+	App           string `config:"app"`
+	Period        string `config:"period"`
+	TimeZone      string `config:"timeZone"`
+	Symlink       string `config:"symlink"`
+	MaxAge        string `config:"maxAge"`
+	MaxFiles      int    `config:"maxFiles"`
+	MaxTotalSize  int64  `config:"maxTotalSize"`
+	Compression   string `config:"compression"`
+	BufferSize    int    `config:"bufferSize"`
+	FlushLevel    string `config:"flushLevel"`
+	FlushInterval string `config:"flushInterval"`
+	Sync          string `config:"sync"`
+
+	// syslog only. The facility is one of "kern", "user" (default), "mail",
+	// "daemon", "auth", "syslog", "lpr", "news", "uucp", "cron", "authpriv"
+	// and "ftp". The format is "bsd" (default) or "rfc5424". The timeout,
+	// minBackoff and maxBackoff are durations. See syslog.Config for the
+	// others.
+	Tag        string `config:"tag"`
+	Facility   string `config:"facility"`
+	Network    string `config:"network"`
+	Addr       string `config:"addr"`
+	Timeout    string `config:"timeout"`
+	Format     string `config:"format"`
+	Hostname   string `config:"hostname"`
+	MsgID      string `config:"msgID"`
+	SDID       string `config:"sdID"`
+	MinBackoff string `config:"minBackoff"`
+	MaxBackoff string `config:"maxBackoff"`
+	SpillSize  int64  `config:"spillSize"`
+	SpillPath  string `config:"spillPath"`
+
+	// usock only. The slowPolicy is "drop" (default) or "disconnect". The
+	// writeTimeout is a duration. See usock.Config for the others.
+	Path         string `config:"path"`
+	MaxClients   int    `config:"maxClients"`
+	QueueSize    int    `config:"queueSize"`
+	SlowPolicy   string `config:"slowPolicy"`
+	WriteTimeout string `config:"writeTimeout"`
+	ReplaySize   int    `config:"replaySize"`
+}
@@ -0,0 +1,229 @@
+package config_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gratonos/gxlog/config"
+	"github.com/gratonos/gxlog/formatter/frame"
+	"github.com/gratonos/gxlog/formatter/json"
+	"github.com/gratonos/gxlog/formatter/msgpack"
+	"github.com/gratonos/gxlog/formatter/text"
+	"github.com/gratonos/gxlog/iface"
+	"github.com/gratonos/gxlog/logger"
+	"github.com/gratonos/gxlog/writer"
+)
+
+func TestParse(t *testing.T) {
+	cfg, err := config.Parse([]byte(`{
+		"Level": "info",
+		"vmodule": "db/*=trace",
+		"slots": [
+			{"formatter": {"header": "std", "colors": {"warn": "cyan"}},
+			 "writer": {"type": "stderr"}},
+			{"level": "warn", "writer": {"type": "file", "maxFileSize": 1024}}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("TestParse: %v", err)
+	}
+	if cfg.Level != "info" || cfg.VModule != "db/*=trace" || len(cfg.Slots) != 2 {
+		t.Fatalf("TestParse: unexpected config: %+v", cfg)
+	}
+	if cfg.Slots[0].Formatter.Colors["warn"] != "cyan" || cfg.Slots[1].Writer.MaxFileSize != 1024 {
+		t.Errorf("TestParse: unexpected slots: %+v", cfg.Slots)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		err  string
+	}{
+		{`{"levels": "info"}`, `<root>: unknown key "levels"`},
+		{`{"slots": [{}, {"writer": {"dirs": "x"}}]}`, `slots[1].writer: unknown key "dirs"`},
+		{`{"slots": [{"writer": {"maxFileSize": "big"}}]}`,
+			`slots[0].writer.maxFileSize: expect integer, got string`},
+		{`{"slots": {"type": "stderr"}}`, `slots: invalid index "type"`},
+		{`[]`, `<root>: expect object, got []interface {}`},
+	}
+	for _, test := range tests {
+		_, err := config.Parse([]byte(test.data))
+		if err == nil || !strings.HasSuffix(err.Error(), test.err) {
+			t.Errorf("TestParseErrors: %s: want error %q, got %v", test.data, test.err, err)
+		}
+	}
+}
+
+func TestEnv(t *testing.T) {
+	cfg, err := config.Parse([]byte(`{"level": "info", "slots": [{"writer": {"type": "stderr"}}]}`))
+	if err != nil {
+		t.Fatalf("TestEnv: %v", err)
+	}
+	t.Setenv("GXLOGTEST_LEVEL", "warn")
+	t.Setenv("GXLOGTEST_SLOTS_1_WRITER_TYPE", "file")
+	t.Setenv("GXLOGTEST_SLOTS_1_WRITER_MAXFILESIZE", "2048")
+	t.Setenv("GXLOGTEST_SLOTS_1_FORMATTER_COLORING", "true")
+	if err := cfg.LoadEnv("GXLOGTEST"); err != nil {
+		t.Fatalf("TestEnv: %v", err)
+	}
+	if cfg.Level != "warn" || len(cfg.Slots) != 2 || cfg.Slots[0].Writer.Type != "stderr" {
+		t.Fatalf("TestEnv: unexpected config: %+v", cfg)
+	}
+	slot := cfg.Slots[1]
+	if slot.Writer.Type != "file" || slot.Writer.MaxFileSize != 2048 || !slot.Formatter.Coloring {
+		t.Errorf("TestEnv: unexpected slot: %+v", slot)
+	}
+
+	t.Setenv("GXLOGTEST_SLOTS_1_WRITER_SIZE", "1")
+	if _, err := config.FromEnv("GXLOGTEST"); err == nil ||
+		!strings.Contains(err.Error(), `slots[1].writer: unknown key "size"`) {
+		t.Errorf("TestEnv: want an unknown key error, got %v", err)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Level:   "debug",
+		VModule: "config=info",
+		Slots: []config.SlotConfig{
+			{},
+			{
+				Level:     "info",
+				Formatter: config.FormatterConfig{Type: "json"},
+				Writer:    config.WriterConfig{Type: "file", Dir: dir},
+			},
+		},
+	}
+	log, err := config.Build(cfg)
+	if err != nil {
+		t.Fatalf("TestBuild: %v", err)
+	}
+	if log.Level() != iface.Debug || log.VModule() != "config=info" {
+		t.Errorf("TestBuild: unexpected logger: %v %q", log.Level(), log.VModule())
+	}
+	if _, ok := log.SlotFormatter(logger.Slot1).(*json.Formatter); !ok {
+		t.Errorf("TestBuild: want a json formatter, got %T", log.SlotFormatter(logger.Slot1))
+	}
+	if log.SlotLevel(logger.Slot0) != iface.Off {
+		t.Errorf("TestBuild: slot 0 should be unused")
+	}
+
+	log.Info("config")
+	if err := log.Close(); err != nil {
+		t.Fatalf("TestBuild: %v", err)
+	}
+	content := readDir(t, dir)
+	if !strings.Contains(content, `"Msg":"config"`) {
+		t.Errorf("TestBuild: unexpected log: %q", content)
+	}
+}
+
+func TestBuildFrame(t *testing.T) {
+	cfg := &config.Config{
+		Slots: []config.SlotConfig{{
+			Formatter: config.FormatterConfig{Type: "msgpack", Frame: "fixed32"},
+			Writer:    config.WriterConfig{Type: "stderr"},
+		}},
+	}
+	log, err := config.Build(cfg)
+	if err != nil {
+		t.Fatalf("TestBuildFrame: %v", err)
+	}
+	fmtr, ok := log.SlotFormatter(logger.Slot0).(*frame.Formatter)
+	if !ok {
+		t.Fatalf("TestBuildFrame: want a frame formatter, got %T", log.SlotFormatter(logger.Slot0))
+	}
+	if _, ok := fmtr.Formatter().(*msgpack.Formatter); !ok {
+		t.Errorf("TestBuildFrame: want a msgpack formatter, got %T", fmtr.Formatter())
+	}
+
+	cfg.Slots[0].Formatter.Frame = "crlf"
+	if _, err := config.Build(cfg); err == nil ||
+		!strings.Contains(err.Error(), `slots[0].formatter.frame: invalid frame "crlf"`) {
+		t.Errorf("TestBuildFrame: want an invalid frame error, got %v", err)
+	}
+
+	cfg.Slots[0].Formatter.Frame = "varint"
+	cfg.Slots[0].Writer = config.WriterConfig{
+		Type: "usock",
+		Path: filepath.Join(t.TempDir(), "usock"),
+	}
+	log, err = config.Build(cfg)
+	if err != nil {
+		t.Fatalf("TestBuildFrame: %v", err)
+	}
+	defer log.Close()
+	// the notices of the usock writer are framed
+	conn, err := net.Dial("unix", cfg.Slots[0].Writer.Path)
+	if err != nil {
+		t.Fatalf("TestBuildFrame: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("{}}\n")); err != nil {
+		t.Fatalf("TestBuildFrame: %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	notice, err := frame.NewReader(conn, frame.Varint, 0).ReadFrame()
+	if err != nil || !strings.HasPrefix(string(notice), "<gxlog: invalid subscription: ") {
+		t.Errorf("TestBuildFrame: unexpected notice: %q, %v", notice, err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	log := logger.New(logger.Config{})
+	formatter := text.New(text.Config{})
+	log.SetSlot(logger.Slot0, logger.Slot{Formatter: formatter, Writer: writer.Null()})
+
+	invalid := []*config.Config{
+		{Level: "loud"},
+		{VModule: "="},
+		{Slots: []config.SlotConfig{{Writer: config.WriterConfig{Type: "tape"}}}},
+		{Slots: []config.SlotConfig{{Formatter: config.FormatterConfig{MarkColor: "pink"}}}},
+		{Slots: make([]config.SlotConfig, logger.MaxSlot+1)},
+	}
+	for _, cfg := range invalid {
+		if err := config.Apply(log, cfg); err == nil {
+			t.Errorf("TestApply: want an error for %+v", cfg)
+		}
+	}
+	if log.Level() != iface.Trace || log.SlotFormatter(logger.Slot0) != formatter {
+		t.Fatalf("TestApply: the logger should NOT be changed")
+	}
+
+	cfg := &config.Config{
+		Level: "warn",
+		Slots: []config.SlotConfig{{
+			Formatter: config.FormatterConfig{Header: "compact"},
+			Writer:    config.WriterConfig{Type: "stdout"},
+		}},
+	}
+	if err := config.Apply(log, cfg); err != nil {
+		t.Fatalf("TestApply: %v", err)
+	}
+	applied, ok := log.SlotFormatter(logger.Slot0).(*text.Formatter)
+	if log.Level() != iface.Warn || !ok || applied.Header() != text.CompactHeader {
+		t.Errorf("TestApply: the config is NOT applied")
+	}
+}
+
+func readDir(t *testing.T, dir string) string {
+	var content []byte
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		bs, err := os.ReadFile(path)
+		content = append(content, bs...)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("readDir: %v", err)
+	}
+	return string(content)
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Parse parses a configuration in JSON.
+func Parse(data []byte) (*Config, error) {
+	return ParseWith(data, json.Unmarshal)
+}
+
+// ParseWith parses a configuration with the unmarshal function, which MUST be
+// able to unmarshal data into an interface{} as a tree of maps, slices and
+// scalars, e.g. json.Unmarshal or Unmarshal of a YAML package.
+func ParseWith(data []byte, unmarshal func(data []byte, v interface{}) error) (*Config, error) {
+	var tree interface{}
+	if err := unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("config.Parse: %v", err)
+	}
+	config := new(Config)
+	if err := decode("", tree, reflect.ValueOf(config).Elem()); err != nil {
+		return nil, fmt.Errorf("config.Parse: %v", err)
+	}
+	return config, nil
+}
+
+// decode overlays src onto dst. Thus, the fields of dst, the elements of
+// slices and the entries of maps that are absent in src are kept.
+func decode(path string, src interface{}, dst reflect.Value) error {
+	if src == nil {
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Struct:
+		return decodeStruct(path, src, dst)
+	case reflect.Slice:
+		return decodeSlice(path, src, dst)
+	case reflect.Map:
+		return decodeMap(path, src, dst)
+	case reflect.String:
+		str, ok := src.(string)
+		if !ok {
+			return typeError(path, "string", src)
+		}
+		dst.SetString(str)
+	case reflect.Bool:
+		switch v := src.(type) {
+		case bool:
+			dst.SetBool(v)
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return typeError(path, "bool", src)
+			}
+			dst.SetBool(b)
+		default:
+			return typeError(path, "bool", src)
+		}
+	case reflect.Int, reflect.Int64:
+		n, ok := toInt(src)
+		if !ok {
+			return typeError(path, "integer", src)
+		}
+		dst.SetInt(n)
+	default:
+		panic("config: unsupported field type: " + dst.Type().String())
+	}
+	return nil
+}
+
+func decodeStruct(path string, src interface{}, dst reflect.Value) error {
+	fields, err := toMap(path, src)
+	if err != nil {
+		return err
+	}
+
+	typ := dst.Type()
+	for _, key := range sortedKeys(fields) {
+		index := -1
+		for i := 0; i < typ.NumField(); i++ {
+			if strings.EqualFold(typ.Field(i).Tag.Get("config"), key) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return fmt.Errorf("%s: unknown key %q", displayPath(path), key)
+		}
+		name := typ.Field(index).Tag.Get("config")
+		if err := decode(joinPath(path, name), fields[key], dst.Field(index)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeSlice(path string, src interface{}, dst reflect.Value) error {
+	var elems []interface{}
+	switch v := src.(type) {
+	case []interface{}:
+		elems = v
+	case map[string]interface{}:
+		// e.g. a tree from environment variables, {"0": ..., "1": ...}
+		for key, elem := range v {
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 {
+				return fmt.Errorf("%s: invalid index %q", displayPath(path), key)
+			}
+			for len(elems) <= i {
+				elems = append(elems, nil)
+			}
+			elems[i] = elem
+		}
+	default:
+		return typeError(path, "list", src)
+	}
+
+	n := dst.Len()
+	if n < len(elems) {
+		n = len(elems)
+	}
+	slice := reflect.MakeSlice(dst.Type(), n, n)
+	reflect.Copy(slice, dst)
+	for i, elem := range elems {
+		if err := decode(fmt.Sprintf("%s[%d]", path, i), elem, slice.Index(i)); err != nil {
+			return err
+		}
+	}
+	dst.Set(slice)
+	return nil
+}
+
+func decodeMap(path string, src interface{}, dst reflect.Value) error {
+	entries, err := toMap(path, src)
+	if err != nil {
+		return err
+	}
+
+	m := reflect.MakeMapWithSize(dst.Type(), dst.Len()+len(entries))
+	iter := dst.MapRange()
+	for iter.Next() {
+		m.SetMapIndex(iter.Key(), iter.Value())
+	}
+	for key, entry := range entries {
+		value := reflect.New(dst.Type().Elem()).Elem()
+		if err := decode(joinPath(path, key), entry, value); err != nil {
+			return err
+		}
+		m.SetMapIndex(reflect.ValueOf(key), value)
+	}
+	dst.Set(m)
+	return nil
+}
+
+func toMap(path string, src interface{}) (map[string]interface{}, error) {
+	switch v := src.(type) {
+	case map[string]interface{}:
+		return v, nil
+	case map[interface{}]interface{}:
+		// e.g. a tree from some YAML packages
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = value
+		}
+		return m, nil
+	default:
+		return nil, typeError(path, "object", src)
+	}
+}
+
+func toInt(src interface{}) (int64, bool) {
+	switch v := src.(type) {
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint64:
+		return int64(v), v <= 1<<63-1
+	case float64:
+		return int64(v), v == float64(int64(v))
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "<root>"
+	}
+	return path
+}
+
+func typeError(path, expect string, src interface{}) error {
+	return fmt.Errorf("%s: expect %s, got %T", displayPath(path), expect, src)
+}
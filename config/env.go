@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// FromEnv returns a configuration from environment variables, see LoadEnv.
+func FromEnv(prefix string) (*Config, error) {
+	config := new(Config)
+	if err := config.LoadEnv(prefix); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// LoadEnv overlays the environment variables whose names begin with the
+// prefix and an underscore onto the configuration. The rest of a name is
+// a path of keys separated by underscores, where indexes of slots are
+// numbers, e.g. GXLOG_LEVEL=info, GXLOG_SLOTS_1_WRITER_MAXFILESIZE=1048576.
+func (this *Config) LoadEnv(prefix string) error {
+	if err := this.loadEnv(prefix, os.Environ()); err != nil {
+		return fmt.Errorf("config.LoadEnv: %v", err)
+	}
+	return nil
+}
+
+func (this *Config) loadEnv(prefix string, environ []string) error {
+	prefix = strings.ToLower(prefix) + "_"
+	tree := make(map[string]interface{})
+	for _, env := range environ {
+		name, value, _ := strings.Cut(env, "=")
+		name = strings.ToLower(name)
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if err := insertEnv(tree, strings.Split(name[len(prefix):], "_"), value); err != nil {
+			return fmt.Errorf("%s: %v", env, err)
+		}
+	}
+	return decode("", tree, reflect.ValueOf(this).Elem())
+}
+
+func insertEnv(tree map[string]interface{}, keys []string, value string) error {
+	for i, key := range keys {
+		if key == "" {
+			return fmt.Errorf("empty key")
+		}
+		if i == len(keys)-1 {
+			if _, ok := tree[key]; ok {
+				return fmt.Errorf("conflicting key %q", key)
+			}
+			tree[key] = value
+			return nil
+		}
+		switch sub := tree[key].(type) {
+		case nil:
+			next := make(map[string]interface{})
+			tree[key] = next
+			tree = next
+		case map[string]interface{}:
+			tree = sub
+		default:
+			return fmt.Errorf("conflicting key %q", key)
+		}
+	}
+	return nil
+}
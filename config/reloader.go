@@ -0,0 +1,325 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gratonos/gxlog/iface"
+	"github.com/gratonos/gxlog/logger"
+	"github.com/gratonos/gxlog/writer/file"
+	"github.com/gratonos/gxlog/writer/syslog"
+)
+
+type ReloaderConfig struct {
+	// Path is the path of the config file.
+	Path string
+	// Interval is the interval to poll the modification of the config file.
+	// If it is NOT positive, the config file is NOT polled.
+	Interval time.Duration
+	// Reload the config file on SIGHUP if it is true.
+	Signal bool
+	// Unmarshal is used to parse the config file, see ParseWith. If it is
+	// nil, json.Unmarshal is used.
+	Unmarshal func(data []byte, v interface{}) error
+	// OnError is called with the error if a triggered reload fails. It is
+	// called in the goroutine of the Reloader.
+	OnError func(err error)
+}
+
+func (this *ReloaderConfig) SetDefaults() {
+	if this.Unmarshal == nil {
+		this.Unmarshal = json.Unmarshal
+	}
+	if this.OnError == nil {
+		this.OnError = func(error) {}
+	}
+}
+
+type loadedSlot struct {
+	config SlotConfig
+	slot   logger.Slot
+}
+
+// A Reloader keeps a Logger configured by a config file. Only the changes of
+// the config file are applied to the Logger, e.g. the writer of a slot is
+// kept if its config is NOT changed, and the Dir of a file writer is changed
+// in place. A writer is closed after it is removed from the Logger.
+// If a reload fails, the Logger is NOT changed and keeps the previous config.
+// The Logger should NOT be configured in other ways while it is managed by a
+// Reloader, or the changes may be overwritten by a reload.
+type Reloader struct {
+	logger    *logger.Logger
+	path      string
+	unmarshal func(data []byte, v interface{}) error
+	onError   func(err error)
+
+	config  *Config
+	slots   [logger.MaxSlot]*loadedSlot
+	modTime time.Time
+	size    int64
+	lock    sync.Mutex
+
+	signals chan os.Signal
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewReloader loads the config file, applies it to the Logger and then
+// watches the config file. The slots of the Logger NOT in the config file are
+// reset and the writers of the Logger are NOT closed.
+func NewReloader(log *logger.Logger, config ReloaderConfig) (*Reloader, error) {
+	config.SetDefaults()
+	reloader := &Reloader{
+		logger:    log,
+		path:      config.Path,
+		unmarshal: config.Unmarshal,
+		onError:   config.OnError,
+		done:      make(chan struct{}),
+	}
+	if err := reloader.reload(true); err != nil {
+		return nil, fmt.Errorf("config.NewReloader: %v", err)
+	}
+
+	if config.Signal {
+		reloader.signals = make(chan os.Signal, 1)
+		signal.Notify(reloader.signals, syscall.SIGHUP)
+	}
+	reloader.wg.Add(1)
+	go reloader.watch(config.Interval)
+	return reloader, nil
+}
+
+// Config returns the config applied currently. Do NOT modify it.
+func (this *Reloader) Config() *Config {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.config
+}
+
+// Reload reloads the config file immediately whether it is modified or NOT.
+func (this *Reloader) Reload() error {
+	if err := this.reload(true); err != nil {
+		return fmt.Errorf("config.Reload: %v", err)
+	}
+	return nil
+}
+
+// Close stops watching the config file. The Logger and its writers are
+// NOT closed.
+func (this *Reloader) Close() error {
+	if this.signals != nil {
+		signal.Stop(this.signals)
+	}
+	close(this.done)
+	this.wg.Wait()
+	return nil
+}
+
+func (this *Reloader) watch(interval time.Duration) {
+	defer this.wg.Done()
+
+	var ticks <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	for {
+		var err error
+		select {
+		case <-this.done:
+			return
+		case <-ticks:
+			err = this.reload(false)
+		case <-this.signals:
+			err = this.reload(true)
+		}
+		if err != nil {
+			this.onError(fmt.Errorf("config.Reloader: %v", err))
+		}
+	}
+}
+
+// reload returns nil without reloading if the config file is NOT modified
+// and force is false.
+func (this *Reloader) reload(force bool) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	info, err := os.Stat(this.path)
+	if err != nil {
+		if !force && this.size < 0 {
+			return nil // the error has been reported
+		}
+		this.size = -1
+		return err
+	}
+	if !force && info.ModTime().Equal(this.modTime) && info.Size() == this.size {
+		return nil
+	}
+	this.modTime = info.ModTime()
+	this.size = info.Size()
+
+	data, err := os.ReadFile(this.path)
+	if err != nil {
+		return err
+	}
+	config, err := ParseWith(data, this.unmarshal)
+	if err != nil {
+		return err
+	}
+	return this.apply(config)
+}
+
+func (this *Reloader) apply(config *Config) error {
+	level, err := parseLevel("level", config.Level)
+	if err != nil {
+		return err
+	}
+	if len(config.Slots) > logger.MaxSlot {
+		return fmt.Errorf("slots: too many slots: %d > %d", len(config.Slots), logger.MaxSlot)
+	}
+
+	var next [logger.MaxSlot]*loadedSlot
+	var kept [logger.MaxSlot]bool    // whether the writer of the previous slot is kept
+	var updates [logger.MaxSlot]bool // whether the kept writer is updated in place
+	var opened []*logger.Slot
+	for i := range config.Slots {
+		path := fmt.Sprintf("slots[%d]", i)
+		slotConfig := &config.Slots[i]
+		prev := this.slots[i]
+		// the notices of a usock writer are framed by the frame of its slot
+		if prev != nil && (prev.config.Formatter.Frame == slotConfig.Formatter.Frame ||
+			!strings.EqualFold(slotConfig.Writer.Type, "usock")) {
+			if reflect.DeepEqual(prev.config.Writer, slotConfig.Writer) {
+				kept[i] = true
+			} else if updatable(&prev.config.Writer, &slotConfig.Writer) {
+				kept[i], updates[i] = true, true
+			}
+		}
+		var slot *logger.Slot
+		if kept[i] {
+			slot, err = buildSlot(path, slotConfig, prev.slot.Writer)
+		} else {
+			slot, err = buildSlot(path, slotConfig, nil)
+			opened = append(opened, slot)
+		}
+		if err != nil {
+			closeSlots(opened)
+			return err
+		}
+		if slot == nil {
+			continue
+		}
+		if kept[i] && reflect.DeepEqual(prev.config.Formatter, slotConfig.Formatter) {
+			slot.Formatter = prev.slot.Formatter
+		}
+		next[i] = &loadedSlot{config: *slotConfig, slot: *slot}
+	}
+
+	// the updates in place are reverted if the reload fails
+	revert := func(n int) {
+		for i := 0; i < n; i++ {
+			if updates[i] {
+				_ = updateWriter("", this.slots[i].slot.Writer, &this.slots[i].config.Writer)
+			}
+		}
+		closeSlots(opened)
+	}
+	for i := range updates {
+		if !updates[i] {
+			continue
+		}
+		path := fmt.Sprintf("slots[%d].writer", i)
+		if err := updateWriter(path, next[i].slot.Writer, &next[i].config.Writer); err != nil {
+			revert(i + 1)
+			return err
+		}
+	}
+
+	var slots []*logger.Slot
+	for _, slot := range next {
+		if slot != nil {
+			slots = append(slots, &slot.slot)
+		} else {
+			slots = append(slots, nil)
+		}
+	}
+	if err := this.logger.Reconfigure(level, config.VModule, slots); err != nil {
+		revert(len(updates))
+		return err
+	}
+	for i, prev := range this.slots {
+		if prev != nil && !kept[i] {
+			if err := closeWriter(prev.slot.Writer); err != nil {
+				this.onError(fmt.Errorf("config.Reloader: slots[%d].writer: %v", i, err))
+			}
+		}
+	}
+
+	this.config = config
+	this.slots = next
+	return nil
+}
+
+// updatable returns whether the writer of the prev config can be changed to
+// the next config in place by the setters of the writer, i.e. only the dir
+// and the maxFileSize of a file writer, or the tag and the facility of a
+// syslog writer are changed.
+func updatable(prev, next *WriterConfig) bool {
+	prevConfig, nextConfig := *prev, *next
+	switch strings.ToLower(next.Type) {
+	case "file":
+		if prev.Dir != "" && next.Dir != "" {
+			prevConfig.Dir, nextConfig.Dir = "", ""
+		}
+		if prev.MaxFileSize > 0 && next.MaxFileSize > 0 {
+			prevConfig.MaxFileSize, nextConfig.MaxFileSize = 0, 0
+		}
+	case "syslog":
+		if prev.Tag != "" && next.Tag != "" {
+			prevConfig.Tag, nextConfig.Tag = "", ""
+		}
+		prevConfig.Facility, nextConfig.Facility = "", ""
+	default:
+		return false
+	}
+	return reflect.DeepEqual(prevConfig, nextConfig)
+}
+
+// updateWriter applies the fields of the config that are changed in place,
+// see updatable.
+func updateWriter(path string, wt iface.Writer, config *WriterConfig) error {
+	switch w := wt.(type) {
+	case *file.Writer:
+		if config.Dir != "" {
+			if err := w.SetDir(config.Dir); err != nil {
+				return fmt.Errorf("%s.dir: %v", path, err)
+			}
+		}
+		if config.MaxFileSize > 0 {
+			if err := w.SetMaxFileSize(config.MaxFileSize); err != nil {
+				return fmt.Errorf("%s.maxFileSize: %v", path, err)
+			}
+		}
+	case *syslog.Writer:
+		facility, err := parseFacility(path+".facility", config.Facility)
+		if err != nil {
+			return err
+		}
+		if config.Tag != "" {
+			w.SetTag(config.Tag)
+		}
+		w.SetFacility(facility)
+	}
+	return nil
+}
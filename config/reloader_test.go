@@ -0,0 +1,126 @@
+package config_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gratonos/gxlog/config"
+	"github.com/gratonos/gxlog/iface"
+	"github.com/gratonos/gxlog/logger"
+	"github.com/gratonos/gxlog/writer/file"
+)
+
+const reloaderTmpl = `{
+	"level": %q,
+	"slots": [{"level": %q, "formatter": {"header": "{{msg}}\n"},
+	           "writer": {"type": "file", "dir": %q}}]
+}`
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gxlog.json")
+	dir1, dir2 := filepath.Join(dir, "1"), filepath.Join(dir, "2")
+	writeConfig(t, path, fmt.Sprintf(reloaderTmpl, "info", "trace", dir1))
+
+	errs := make(chan error, 16)
+	log := logger.New(logger.Config{})
+	reloader, err := config.NewReloader(log, config.ReloaderConfig{
+		Path:     path,
+		Interval: 10 * time.Millisecond,
+		OnError:  func(err error) { errs <- err },
+	})
+	if err != nil {
+		t.Fatalf("TestReloader: %v", err)
+	}
+	defer reloader.Close()
+	writer1 := log.SlotWriter(logger.Slot0).(*file.Writer)
+	if log.Level() != iface.Info || writer1.Dir() != dir1 {
+		t.Fatalf("TestReloader: the config is NOT applied")
+	}
+
+	writeConfig(t, path, fmt.Sprintf(reloaderTmpl, "debug", "warn", dir1))
+	waitFor(t, func() bool { return log.SlotLevel(logger.Slot0) == iface.Warn })
+	if log.Level() != iface.Debug || log.SlotWriter(logger.Slot0) != writer1 {
+		t.Errorf("TestReloader: the writer should be kept")
+	}
+
+	writeConfig(t, path, `{"level": "bad"}`)
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), `level: invalid level "bad"`) {
+			t.Errorf("TestReloader: unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("TestReloader: no error is reported")
+	}
+	if log.Level() != iface.Debug || log.SlotWriter(logger.Slot0) != writer1 {
+		t.Errorf("TestReloader: the previous config should be kept")
+	}
+
+	writeConfig(t, path, fmt.Sprintf(reloaderTmpl, "debug", "warn", dir2))
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("TestReloader: %v", err)
+	}
+	if log.SlotWriter(logger.Slot0) != writer1 || writer1.Dir() != dir2 {
+		t.Fatalf("TestReloader: the dir should be changed in place")
+	}
+	log.Warn("moved")
+
+	writeConfig(t, path, fmt.Sprintf(`{"slots": [{"formatter": {"header": "{{msg}}\n"},
+		"writer": {"type": "file", "dir": %q, "app": "other"}}]}`, dir1))
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("TestReloader: %v", err)
+	}
+	writer2 := log.SlotWriter(logger.Slot0).(*file.Writer)
+	if writer2 == writer1 || writer2.Dir() != dir1 {
+		t.Fatalf("TestReloader: the writer should be reopened")
+	}
+	if err := log.Close(); err != nil {
+		t.Fatalf("TestReloader: %v", err)
+	}
+	if content := readDir(t, dir2); content != "moved\n" {
+		t.Errorf("TestReloader: unexpected log: %q", content)
+	}
+}
+
+func TestReloaderSignal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gxlog.json")
+	writeConfig(t, path, `{"level": "info"}`)
+
+	log := logger.New(logger.Config{})
+	reloader, err := config.NewReloader(log, config.ReloaderConfig{Path: path, Signal: true})
+	if err != nil {
+		t.Fatalf("TestReloaderSignal: %v", err)
+	}
+	defer reloader.Close()
+
+	writeConfig(t, path, `{"level": "error"}`)
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("TestReloaderSignal: %v", err)
+	}
+	waitFor(t, func() bool { return log.Level() == iface.Error })
+}
+
+func writeConfig(t *testing.T, path, content string) {
+	// rename to make the modification atomic to the Reloader
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+		t.Fatalf("writeConfig: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("writeConfig: %v", err)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	for deadline := time.Now().Add(time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("waitFor: timeout")
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package cbor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gratonos/gxlog/iface"
+)
+
+var errShortData = errors.New("unexpected end of data")
+
+var majorNames = []string{
+	majorUint:   "uint",
+	majorNegInt: "negative int",
+	majorBytes:  "bytes",
+	majorText:   "text",
+	majorArray:  "array",
+	majorMap:    "map",
+	majorTag:    "tag",
+	majorSimple: "simple or float",
+}
+
+// Decode decodes a Record from the data, which MUST be exactly one output of
+// a Formatter. Unknown fields are skipped. The times are in the local time
+// zone. The values of errors are reconstructed by errors.New, and the values
+// of KindAny are decoded as strings. Items of indefinite lengths are NOT
+// supported.
+func Decode(data []byte) (*iface.Record, error) {
+	decoder := &decoder{data: data}
+	record, err := decoder.decodeRecord()
+	if err == nil && decoder.pos != len(data) {
+		err = fmt.Errorf("%d bytes of trailing data", len(data)-decoder.pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cbor.Decode: %v", err)
+	}
+	return record, nil
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (this *decoder) decodeRecord() (*iface.Record, error) {
+	n, err := this.readLength(majorMap)
+	if err != nil {
+		return nil, err
+	}
+	record := &iface.Record{}
+	for i := 0; i < n; i++ {
+		key, err := this.readText()
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case keyTime:
+			record.Time, err = this.readTime()
+		case keyLevel:
+			var level int64
+			level, err = this.readInt()
+			record.Level = iface.Level(level)
+		case keyFile:
+			record.File, err = this.readText()
+		case keyLine:
+			var line int64
+			line, err = this.readInt()
+			record.Line = int(line)
+		case keyPkg:
+			record.Pkg, err = this.readText()
+		case keyFunc:
+			record.Func, err = this.readText()
+		case keyMsg:
+			record.Msg, err = this.readText()
+		case keyStack:
+			record.Stack, err = this.readText()
+		case keyPrefix:
+			record.Prefix, err = this.readText()
+		case keyContexts:
+			record.Contexts, err = this.readContexts()
+		case keyMark:
+			record.Mark, err = this.readBool()
+		default:
+			err = this.skip()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+	}
+	return record, nil
+}
+
+func (this *decoder) readContexts() ([]iface.Context, error) {
+	n, err := this.readLength(majorArray)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	contexts := make([]iface.Context, 0, n)
+	for i := 0; i < n; i++ {
+		m, err := this.readLength(majorArray)
+		if err != nil {
+			return nil, err
+		}
+		if m != 3 {
+			return nil, fmt.Errorf("invalid context of %d elements", m)
+		}
+		key, err := this.readText()
+		if err != nil {
+			return nil, err
+		}
+		kind, err := this.readUint()
+		if err != nil {
+			return nil, err
+		}
+		value, err := this.readValue(iface.Kind(kind))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+		contexts = append(contexts, iface.Context{Key: key, Value: value})
+	}
+	return contexts, nil
+}
+
+func (this *decoder) readValue(kind iface.Kind) (iface.Value, error) {
+	var value iface.Value
+	var err error
+	switch kind {
+	case iface.KindString, iface.KindAny:
+		var text string
+		text, err = this.readText()
+		value = iface.StringValue(text)
+	case iface.KindInt64:
+		var n int64
+		n, err = this.readInt()
+		value = iface.Int64Value(n)
+	case iface.KindUint64:
+		var n uint64
+		n, err = this.readUint()
+		value = iface.Uint64Value(n)
+	case iface.KindFloat64:
+		var f float64
+		f, err = this.readFloat()
+		value = iface.Float64Value(f)
+	case iface.KindBool:
+		var ok bool
+		ok, err = this.readBool()
+		value = iface.BoolValue(ok)
+	case iface.KindDuration:
+		var n int64
+		n, err = this.readInt()
+		value = iface.DurationValue(time.Duration(n))
+	case iface.KindTime:
+		var t time.Time
+		t, err = this.readTime()
+		value = iface.TimeValue(t)
+	case iface.KindError:
+		var text string
+		text, err = this.readText()
+		value = iface.ErrorValue(errors.New(text))
+	case iface.KindObject:
+		var contexts []iface.Context
+		contexts, err = this.readContexts()
+		value = iface.ObjectValue(contexts...)
+	default:
+		err = fmt.Errorf("unknown kind %d", kind)
+	}
+	return value, err
+}
+
+// readHead reads the initial byte and the argument of a data item. The info
+// is the lower 5 bits of the initial byte.
+func (this *decoder) readHead() (major, info byte, arg uint64, err error) {
+	if this.pos >= len(this.data) {
+		return 0, 0, 0, errShortData
+	}
+	b := this.data[this.pos]
+	this.pos++
+	major, info = b>>5, b&0x1f
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info <= 27:
+		var bs []byte
+		if bs, err = this.readN(1 << (info - 24)); err != nil {
+			return 0, 0, 0, err
+		}
+		for _, x := range bs {
+			arg = arg<<8 | uint64(x)
+		}
+	case info == 31:
+		err = errors.New("indefinite length is not supported")
+	default:
+		err = fmt.Errorf("invalid initial byte 0x%02x", b)
+	}
+	return major, info, arg, err
+}
+
+func (this *decoder) readArg(expect byte) (uint64, error) {
+	major, _, arg, err := this.readHead()
+	if err != nil {
+		return 0, err
+	}
+	if major != expect {
+		return 0, unexpected(major, expect)
+	}
+	return arg, nil
+}
+
+func (this *decoder) readLength(major byte) (int, error) {
+	n, err := this.readArg(major)
+	if err != nil {
+		return 0, err
+	}
+	// every element takes at least one byte
+	if n > uint64(len(this.data)-this.pos) {
+		return 0, errShortData
+	}
+	return int(n), nil
+}
+
+func (this *decoder) readText() (string, error) {
+	n, err := this.readLength(majorText)
+	if err != nil {
+		return "", err
+	}
+	bs, err := this.readN(n)
+	return string(bs), err
+}
+
+func (this *decoder) readInt() (int64, error) {
+	major, _, arg, err := this.readHead()
+	if err != nil {
+		return 0, err
+	}
+	if major != majorUint && major != majorNegInt {
+		return 0, unexpected(major, majorUint)
+	}
+	if arg > math.MaxInt64 {
+		return 0, errors.New("integer overflow")
+	}
+	if major == majorNegInt {
+		return -1 - int64(arg), nil
+	}
+	return int64(arg), nil
+}
+
+func (this *decoder) readUint() (uint64, error) {
+	return this.readArg(majorUint)
+}
+
+func (this *decoder) readFloat() (float64, error) {
+	major, info, arg, err := this.readHead()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case major == majorSimple && info == simpleFloat64:
+		return math.Float64frombits(arg), nil
+	case major == majorSimple && info == simpleFloat32:
+		return float64(math.Float32frombits(uint32(arg))), nil
+	default:
+		return 0, fmt.Errorf("expect float, got %s of 0x%x", majorNames[major], arg)
+	}
+}
+
+func (this *decoder) readBool() (bool, error) {
+	arg, err := this.readArg(majorSimple)
+	if err != nil {
+		return false, err
+	}
+	switch arg {
+	case simpleFalse:
+		return false, nil
+	case simpleTrue:
+		return true, nil
+	default:
+		return false, fmt.Errorf("expect bool, got simple value %d", arg)
+	}
+}
+
+// readTime reads an extended time, or an epoch time of integer seconds.
+func (this *decoder) readTime() (time.Time, error) {
+	tag, err := this.readArg(majorTag)
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch tag {
+	case tagEpochTime:
+		sec, err := this.readInt()
+		return time.Unix(sec, 0), err
+	case tagExtendedTime:
+		n, err := this.readLength(majorMap)
+		if err != nil {
+			return time.Time{}, err
+		}
+		var sec, nsec int64
+		for i := 0; i < n; i++ {
+			key, err := this.readInt()
+			if err != nil {
+				return time.Time{}, err
+			}
+			switch key {
+			case keySeconds:
+				sec, err = this.readInt()
+			case keyNanoseconds:
+				nsec, err = this.readInt()
+			default:
+				err = this.skip()
+			}
+			if err != nil {
+				return time.Time{}, err
+			}
+		}
+		return time.Unix(sec, nsec), nil
+	default:
+		return time.Time{}, fmt.Errorf("expect time, got tag %d", tag)
+	}
+}
+
+// skip skips a data item of any type.
+func (this *decoder) skip() error {
+	major, _, arg, err := this.readHead()
+	if err != nil {
+		return err
+	}
+	var elems uint64
+	switch major {
+	case majorBytes, majorText:
+		if arg > uint64(len(this.data)-this.pos) {
+			return errShortData
+		}
+		this.pos += int(arg)
+	case majorArray:
+		elems = arg
+	case majorMap:
+		elems = arg * 2
+	case majorTag:
+		elems = 1
+	}
+	for i := uint64(0); i < elems; i++ {
+		if err = this.skip(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *decoder) readN(n int) ([]byte, error) {
+	if n > len(this.data)-this.pos {
+		return nil, errShortData
+	}
+	bs := this.data[this.pos : this.pos+n]
+	this.pos += n
+	return bs, nil
+}
+
+func unexpected(major, expect byte) error {
+	return fmt.Errorf("expect %s, got %s", majorNames[expect], majorNames[major])
+}
@@ -0,0 +1,184 @@
+// Package cbor implements a CBOR (RFC 8949) formatter and its decoder.
+//
+// A Record is encoded as a map from the field names, e.g. "Time" and
+// "Level", to the values. The Time is an extended time (tag 1001 of RFC 9581)
+// of the seconds and the nanoseconds. A Context is encoded as an array of its
+// key, its kind and its value. The values of objects are arrays of contexts,
+// the values of times are extended times, the values of durations are
+// nanoseconds, and the values of errors and the others of KindAny are strings.
+package cbor
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gratonos/gxlog/iface"
+)
+
+// The field names of a Record.
+const (
+	keyTime     = "Time"
+	keyLevel    = "Level"
+	keyFile     = "File"
+	keyLine     = "Line"
+	keyPkg      = "Pkg"
+	keyFunc     = "Func"
+	keyMsg      = "Msg"
+	keyStack    = "Stack"
+	keyPrefix   = "Prefix"
+	keyContexts = "Contexts"
+	keyMark     = "Mark"
+	fieldCount  = 11
+)
+
+// The major types.
+const (
+	majorUint   = 0
+	majorNegInt = 1
+	majorBytes  = 2
+	majorText   = 3
+	majorArray  = 4
+	majorMap    = 5
+	majorTag    = 6
+	majorSimple = 7
+)
+
+const (
+	simpleFalse   = 20
+	simpleTrue    = 21
+	simpleFloat32 = 26
+	simpleFloat64 = 27
+)
+
+const (
+	tagEpochTime    = 1
+	tagExtendedTime = 1001
+	// the keys of the map of an extended time
+	keySeconds     = 1
+	keyNanoseconds = -9
+)
+
+type Formatter struct {
+	buf  []byte
+	lock sync.Mutex
+}
+
+func New() *Formatter {
+	return &Formatter{}
+}
+
+func (this *Formatter) Format(record *iface.Record) []byte {
+	this.lock.Lock()
+
+	buf := this.buf[:0]
+	buf = appendHead(buf, majorMap, fieldCount)
+	buf = appendText(buf, keyTime)
+	buf = appendTime(buf, record.Time)
+	buf = appendText(buf, keyLevel)
+	buf = appendInt(buf, int64(record.Level))
+	buf = appendText(buf, keyFile)
+	buf = appendText(buf, record.File)
+	buf = appendText(buf, keyLine)
+	buf = appendInt(buf, int64(record.Line))
+	buf = appendText(buf, keyPkg)
+	buf = appendText(buf, record.Pkg)
+	buf = appendText(buf, keyFunc)
+	buf = appendText(buf, record.Func)
+	buf = appendText(buf, keyMsg)
+	buf = appendText(buf, record.Msg)
+	buf = appendText(buf, keyStack)
+	buf = appendText(buf, record.Stack)
+	buf = appendText(buf, keyPrefix)
+	buf = appendText(buf, record.Prefix)
+	buf = appendText(buf, keyContexts)
+	buf = appendContexts(buf, record.Contexts)
+	buf = appendText(buf, keyMark)
+	buf = appendBool(buf, record.Mark)
+	this.buf = buf
+
+	this.lock.Unlock()
+
+	return buf
+}
+
+func appendContexts(buf []byte, contexts []iface.Context) []byte {
+	buf = appendHead(buf, majorArray, uint64(len(contexts)))
+	for _, context := range contexts {
+		buf = appendHead(buf, majorArray, 3)
+		buf = appendText(buf, context.Key)
+		buf = appendHead(buf, majorUint, uint64(context.Value.Kind()))
+		buf = appendValue(buf, context.Value)
+	}
+	return buf
+}
+
+func appendValue(buf []byte, value iface.Value) []byte {
+	switch value.Kind() {
+	case iface.KindString:
+		return appendText(buf, value.Str())
+	case iface.KindInt64:
+		return appendInt(buf, value.Int64())
+	case iface.KindUint64:
+		return appendHead(buf, majorUint, value.Uint64())
+	case iface.KindFloat64:
+		buf = append(buf, majorSimple<<5|simpleFloat64)
+		return binary.BigEndian.AppendUint64(buf, math.Float64bits(value.Float64()))
+	case iface.KindBool:
+		return appendBool(buf, value.Bool())
+	case iface.KindDuration:
+		return appendInt(buf, int64(value.Duration()))
+	case iface.KindTime:
+		return appendTime(buf, value.Time())
+	case iface.KindObject:
+		return appendContexts(buf, value.Object())
+	default:
+		return appendText(buf, value.String())
+	}
+}
+
+// appendHead appends the initial byte and the argument of a data item in the
+// shortest form.
+func appendHead(buf []byte, major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return append(buf, major<<5|byte(arg))
+	case arg <= math.MaxUint8:
+		return append(buf, major<<5|24, byte(arg))
+	case arg <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, major<<5|25), uint16(arg))
+	case arg <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, major<<5|26), uint32(arg))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, major<<5|27), arg)
+	}
+}
+
+func appendText(buf []byte, text string) []byte {
+	buf = appendHead(buf, majorText, uint64(len(text)))
+	return append(buf, text...)
+}
+
+func appendInt(buf []byte, n int64) []byte {
+	if n < 0 {
+		return appendHead(buf, majorNegInt, uint64(-1-n))
+	}
+	return appendHead(buf, majorUint, uint64(n))
+}
+
+func appendBool(buf []byte, ok bool) []byte {
+	if ok {
+		return append(buf, majorSimple<<5|simpleTrue)
+	}
+	return append(buf, majorSimple<<5|simpleFalse)
+}
+
+func appendTime(buf []byte, t time.Time) []byte {
+	buf = appendHead(buf, majorTag, tagExtendedTime)
+	buf = appendHead(buf, majorMap, 2)
+	buf = appendInt(buf, keySeconds)
+	buf = appendInt(buf, t.Unix())
+	buf = appendInt(buf, keyNanoseconds)
+	return appendInt(buf, int64(t.Nanosecond()))
+}
@@ -0,0 +1,158 @@
+package cbor_test
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gratonos/gxlog/formatter/cbor"
+	"github.com/gratonos/gxlog/iface"
+)
+
+func TestRoundTrip(t *testing.T) {
+	formatter := cbor.New()
+	records := []*iface.Record{
+		{
+			Time:   time.Date(2026, 10, 18, 18, 15, 0, 123456789, time.UTC),
+			Level:  iface.Warn,
+			File:   "/src/app/main.go",
+			Line:   42,
+			Pkg:    "github.com/org/app",
+			Func:   "run",
+			Msg:    strings.Repeat("a message\n", 30),
+			Stack:  "main.main()\n",
+			Prefix: "** ",
+			Contexts: []iface.Context{
+				iface.String("str", "alice"),
+				iface.Int("int", -1),
+				iface.Int64("int64", math.MinInt64),
+				iface.Uint64("uint64", math.MaxUint64),
+				iface.Float64("float64", 3.14),
+				iface.Bool("bool", true),
+				iface.Duration("duration", -time.Second),
+				iface.Time("time", time.Date(1900, 1, 1, 0, 0, 0, 1, time.UTC)),
+				iface.Err("error", errors.New("oops")),
+				iface.Object("object",
+					iface.Int("n", 1000),
+					iface.Object("empty"),
+				),
+			},
+			Mark: true,
+		},
+		{
+			Level: iface.Trace,
+		},
+		{
+			Time:  time.Date(2600, 1, 1, 0, 0, 0, 999999999, time.UTC),
+			Level: iface.Fatal,
+			Line:  70000,
+		},
+	}
+	for _, record := range records {
+		data := formatter.Format(record)
+		decoded, err := cbor.Decode(data)
+		if err != nil {
+			t.Fatalf("TestRoundTrip: %v", err)
+		}
+		if !equalRecords(decoded, record) {
+			t.Errorf("TestRoundTrip:\ndecoded: %+v\nexpect:  %+v", decoded, record)
+		}
+	}
+}
+
+func TestDecodeAny(t *testing.T) {
+	type point struct{ X, Y int }
+	record := &iface.Record{
+		Contexts: []iface.Context{iface.Any("point", point{1, 2})},
+	}
+	decoded, err := cbor.Decode(cbor.New().Format(record))
+	if err != nil {
+		t.Fatalf("TestDecodeAny: %v", err)
+	}
+	value := decoded.Contexts[0].Value
+	if value.Kind() != iface.KindString || value.Str() != "{1 2}" {
+		t.Errorf("TestDecodeAny: kind: %d, value: %s", value.Kind(), value.Str())
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	data := cbor.New().Format(&iface.Record{Msg: "msg"})
+	for i := 0; i < len(data); i++ {
+		if _, err := cbor.Decode(data[:i]); err == nil {
+			t.Errorf("TestDecodeError: no error for %d of %d bytes", i, len(data))
+		}
+	}
+	if _, err := cbor.Decode(append(data, 0)); err == nil {
+		t.Errorf("TestDecodeError: no error for trailing data")
+	}
+
+	// a map of one pair with the key
+	field := func(key string) []byte {
+		return append([]byte{0xa1, 0x60 | byte(len(key))}, key...)
+	}
+	cases := []struct {
+		name   string
+		data   []byte
+		expect string
+	}{
+		{"wrong major type", []byte{0x81, 0x00}, "expect map, got array"},
+		{"wrong major type of a field", append(field("Msg"), 0x01), "Msg: expect text, got uint"},
+		{"indefinite length", []byte{0xbf, 0xff}, "indefinite length is not supported"},
+		{"indefinite length of a field", append(field("Contexts"), 0x9f, 0xff),
+			"Contexts: indefinite length is not supported"},
+		{"huge declared length", append(field("Contexts"),
+			0x9b, 0x7f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff), "unexpected end of data"},
+		{"huge declared length of a text", append(field("Msg"), 0x7a, 0xff, 0xff, 0xff, 0xff),
+			"unexpected end of data"},
+		{"unknown tag", append(field("Time"), 0xd8, 0x64, 0x00), "Time: expect time, got tag 100"},
+		{"reserved initial byte", append(field("Line"), 0x1c), "Line: invalid initial byte 0x1c"},
+	}
+	for _, c := range cases {
+		_, err := cbor.Decode(c.data)
+		if err == nil || !strings.Contains(err.Error(), c.expect) {
+			t.Errorf("TestDecodeError: %s: want %q, got %v", c.name, c.expect, err)
+		}
+	}
+}
+
+func equalRecords(lhs, rhs *iface.Record) bool {
+	return lhs.Time.Equal(rhs.Time) &&
+		lhs.Level == rhs.Level &&
+		lhs.File == rhs.File &&
+		lhs.Line == rhs.Line &&
+		lhs.Pkg == rhs.Pkg &&
+		lhs.Func == rhs.Func &&
+		lhs.Msg == rhs.Msg &&
+		lhs.Stack == rhs.Stack &&
+		lhs.Prefix == rhs.Prefix &&
+		equalContexts(lhs.Contexts, rhs.Contexts) &&
+		lhs.Mark == rhs.Mark
+}
+
+func equalContexts(lhs, rhs []iface.Context) bool {
+	if len(lhs) != len(rhs) {
+		return false
+	}
+	for i := range lhs {
+		if lhs[i].Key != rhs[i].Key || lhs[i].Value.Kind() != rhs[i].Value.Kind() {
+			return false
+		}
+		switch lhs[i].Value.Kind() {
+		case iface.KindTime:
+			if !lhs[i].Value.Time().Equal(rhs[i].Value.Time()) {
+				return false
+			}
+		case iface.KindObject:
+			if !equalContexts(lhs[i].Value.Object(), rhs[i].Value.Object()) {
+				return false
+			}
+		default:
+			if lhs[i].Value.String() != rhs[i].Value.String() {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,19 @@
+package ecs
+
+type Config struct {
+	// The segments of File, Pkg and Func, e.g. a FileSegs of 1 renders the
+	// base name of the file as ECS suggests.
+	FileSegs int
+	PkgSegs  int
+	FuncSegs int
+	// ContextsKey is the key of the object that the Contexts are nested in,
+	// thus the keys of the Contexts never collide with the ECS fields. The
+	// default is "context".
+	ContextsKey string
+}
+
+func (this *Config) SetDefaults() {
+	if this.ContextsKey == "" {
+		this.ContextsKey = "context"
+	}
+}
@@ -0,0 +1,181 @@
+// Package ecs implements a json formatter of the Elastic Common Schema.
+package ecs
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gratonos/gxlog/formatter/internal/util"
+	"github.com/gratonos/gxlog/iface"
+)
+
+// Version is the version of ECS that the logs conform to.
+const Version = "8.11.0"
+
+// A Formatter maps the fields of a Record onto ECS as follows:
+//
+//	Time     -> @timestamp (in UTC)
+//	Level    -> log.level, e.g. "info"
+//	File     -> log.origin.file.name
+//	Line     -> log.origin.file.line
+//	Pkg      -> log.logger
+//	Func     -> log.origin.function
+//	Msg      -> message
+//	Stack    -> error.stack_trace
+//	Prefix   -> labels.prefix
+//	Contexts -> context.* (see Config.ContextsKey)
+//	Mark     -> labels.mark
+//
+// The empty ones of the Stack, Prefix, Contexts and Mark are omitted.
+type Formatter struct {
+	fileSegs    int
+	pkgSegs     int
+	funcSegs    int
+	contextsKey string
+
+	buf  []byte
+	lock sync.Mutex
+}
+
+func New(config Config) *Formatter {
+	config.SetDefaults()
+
+	return &Formatter{
+		fileSegs:    config.FileSegs,
+		pkgSegs:     config.PkgSegs,
+		funcSegs:    config.FuncSegs,
+		contextsKey: config.ContextsKey,
+	}
+}
+
+func (this *Formatter) Format(record *iface.Record) []byte {
+	this.lock.Lock()
+
+	buf := this.buf[:0]
+	buf = append(buf, `{"@timestamp":"`...)
+	buf = record.Time.UTC().AppendFormat(buf, time.RFC3339Nano)
+	buf = append(buf, `","log.level":"`...)
+	buf = append(buf, record.Level.String()...)
+	buf = append(buf, `","message":`...)
+	buf = util.AppendJSONString(buf, record.Msg)
+	buf = append(buf, `,"ecs.version":"`+Version+`"`...)
+
+	buf = append(buf, `,"log":{"logger":`...)
+	buf = util.AppendJSONString(buf, util.LastSegments(record.Pkg, this.pkgSegs, '/'))
+	buf = append(buf, `,"origin":{"file":{"name":`...)
+	file := util.LastSegments(record.File, this.fileSegs, '/')
+	// avoid to omit the root path '/'
+	if len(file)+1 == len(record.File) {
+		file = record.File
+	}
+	buf = util.AppendJSONString(buf, file)
+	buf = append(buf, `,"line":`...)
+	buf = strconv.AppendInt(buf, int64(record.Line), 10)
+	buf = append(buf, `},"function":`...)
+	buf = util.AppendJSONString(buf, util.LastSegments(record.Func, this.funcSegs, '.'))
+	buf = append(buf, "}}"...)
+
+	if record.Stack != "" {
+		buf = append(buf, `,"error":{"stack_trace":`...)
+		buf = util.AppendJSONString(buf, record.Stack)
+		buf = append(buf, "}"...)
+	}
+	if record.Prefix != "" || record.Mark {
+		buf = append(buf, `,"labels":{`...)
+		if record.Prefix != "" {
+			buf = append(buf, `"prefix":`...)
+			buf = util.AppendJSONString(buf, record.Prefix)
+		}
+		if record.Mark {
+			if record.Prefix != "" {
+				buf = append(buf, ","...)
+			}
+			// the values of labels are keywords
+			buf = append(buf, `"mark":"true"`...)
+		}
+		buf = append(buf, "}"...)
+	}
+	if len(record.Contexts) > 0 {
+		buf = append(buf, ","...)
+		buf = util.AppendJSONString(buf, this.contextsKey)
+		buf = append(buf, ":{"...)
+		for i, context := range record.Contexts {
+			if i > 0 {
+				buf = append(buf, ","...)
+			}
+			buf = util.AppendJSONString(buf, context.Key)
+			buf = append(buf, ":"...)
+			buf = util.AppendJSONValue(buf, context.Value)
+		}
+		buf = append(buf, "}"...)
+	}
+
+	buf = append(buf, "}\n"...)
+	this.buf = buf
+
+	this.lock.Unlock()
+
+	return buf
+}
+
+func (this *Formatter) FileSegs() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.fileSegs
+}
+
+func (this *Formatter) SetFileSegs(segs int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.fileSegs = segs
+}
+
+func (this *Formatter) PkgSegs() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.pkgSegs
+}
+
+func (this *Formatter) SetPkgSegs(segs int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.pkgSegs = segs
+}
+
+func (this *Formatter) FuncSegs() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.funcSegs
+}
+
+func (this *Formatter) SetFuncSegs(segs int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.funcSegs = segs
+}
+
+func (this *Formatter) ContextsKey() string {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.contextsKey
+}
+
+// SetContextsKey sets the key of the object of the Contexts. The default key
+// is used if the key is empty.
+func (this *Formatter) SetContextsKey(key string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if key == "" {
+		key = "context"
+	}
+	this.contextsKey = key
+}
@@ -0,0 +1,54 @@
+package ecs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gratonos/gxlog/formatter/ecs"
+	"github.com/gratonos/gxlog/iface"
+)
+
+func TestFormat(t *testing.T) {
+	formatter := ecs.New(ecs.Config{FileSegs: 1})
+	record := &iface.Record{
+		Time:     time.Date(2026, 10, 18, 18, 15, 0, 123456000, time.FixedZone("CST", 8*3600)),
+		Level:    iface.Warn,
+		File:     "/src/app/main.go",
+		Line:     42,
+		Pkg:      "github.com/org/app",
+		Func:     "run",
+		Msg:      `a "message"`,
+		Stack:    "main.main()\n",
+		Prefix:   "** ",
+		Contexts: []iface.Context{iface.String("user", "alice"), iface.Int("n", 1)},
+		Mark:     true,
+	}
+	expect := `{"@timestamp":"2026-10-18T10:15:00.123456Z","log.level":"warn",` +
+		`"message":"a \"message\"","ecs.version":"` + ecs.Version + `",` +
+		`"log":{"logger":"github.com/org/app","origin":{"file":{"name":"main.go","line":42},"function":"run"}},` +
+		`"error":{"stack_trace":"main.main()\n"},"labels":{"prefix":"** ","mark":"true"},` +
+		`"context":{"user":"alice","n":1}}` + "\n"
+	if output := string(formatter.Format(record)); output != expect {
+		t.Errorf("TestFormat:\noutput: %s\nexpect: %s", output, expect)
+	}
+
+	// the contexts never collide with the ECS fields
+	formatter.SetContextsKey("app")
+	record.Contexts = []iface.Context{iface.String("message", "shadowed"), iface.Int("log", 1)}
+	expect = `{"@timestamp":"2026-10-18T10:15:00.123456Z","log.level":"warn",` +
+		`"message":"a \"message\"","ecs.version":"` + ecs.Version + `",` +
+		`"log":{"logger":"github.com/org/app","origin":{"file":{"name":"main.go","line":42},"function":"run"}},` +
+		`"error":{"stack_trace":"main.main()\n"},"labels":{"prefix":"** ","mark":"true"},` +
+		`"app":{"message":"shadowed","log":1}}` + "\n"
+	if output := string(formatter.Format(record)); output != expect {
+		t.Errorf("TestFormat:\noutput: %s\nexpect: %s", output, expect)
+	}
+
+	record.Stack, record.Prefix, record.Mark, record.Contexts = "", "", false, nil
+	expect = `{"@timestamp":"2026-10-18T10:15:00.123456Z","log.level":"warn",` +
+		`"message":"a \"message\"","ecs.version":"` + ecs.Version + `",` +
+		`"log":{"logger":"github.com/org/app","origin":{"file":{"name":"main.go","line":42},"function":"run"}}}` + "\n"
+	if output := string(formatter.Format(record)); output != expect {
+		t.Errorf("TestFormat:\noutput: %s\nexpect: %s", output, expect)
+	}
+}
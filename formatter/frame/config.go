@@ -0,0 +1,29 @@
+package frame
+
+import (
+	"github.com/gratonos/gxlog/formatter"
+	"github.com/gratonos/gxlog/iface"
+)
+
+// A Length specifies the encoding of the length prefixes.
+type Length int
+
+const (
+	// Varint is an unsigned varint of encoding/binary, i.e. LEB128.
+	Varint Length = iota
+	// Fixed32 is a 4-byte unsigned integer in big-endian.
+	Fixed32
+)
+
+type Config struct {
+	// Formatter is the wrapped formatter. The default is formatter.Null().
+	Formatter iface.Formatter
+	// Length is the encoding of the length prefixes. The default is Varint.
+	Length Length
+}
+
+func (this *Config) SetDefaults() {
+	if this.Formatter == nil {
+		this.Formatter = formatter.Null()
+	}
+}
@@ -0,0 +1,79 @@
+// Package frame implements a wrapper that prefixes the outputs of any
+// formatter with their lengths, and a Reader that splits a stream of the
+// outputs back into frames. Frames are safe for the outputs that contain
+// newlines and for binary outputs, e.g. those of formatter/msgpack.
+package frame
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"github.com/gratonos/gxlog/formatter"
+	"github.com/gratonos/gxlog/iface"
+)
+
+// A Formatter wraps another formatter, and emits each of its outputs as a
+// frame of a length prefix followed by the output.
+type Formatter struct {
+	formatter iface.Formatter
+	length    Length
+
+	buf  []byte
+	lock sync.Mutex
+}
+
+func New(config Config) *Formatter {
+	config.SetDefaults()
+
+	return &Formatter{
+		formatter: config.Formatter,
+		length:    config.Length,
+	}
+}
+
+func (this *Formatter) Format(record *iface.Record) []byte {
+	this.lock.Lock()
+
+	buf := appendFrame(this.buf[:0], this.formatter.Format(record), this.length)
+	this.buf = buf
+
+	this.lock.Unlock()
+
+	return buf
+}
+
+// Frame returns a new frame of the data with the length prefix of the
+// Formatter, e.g. to frame the notices of writer/usock, see usock.Config.
+func (this *Formatter) Frame(data []byte) []byte {
+	return appendFrame(make([]byte, 0, binary.MaxVarintLen64+len(data)), data, this.length)
+}
+
+func (this *Formatter) Formatter() iface.Formatter {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.formatter
+}
+
+// SetFormatter sets the wrapped formatter. A nil formatter is replaced by
+// formatter.Null().
+func (this *Formatter) SetFormatter(fmtr iface.Formatter) {
+	if fmtr == nil {
+		fmtr = formatter.Null()
+	}
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.formatter = fmtr
+}
+
+func appendFrame(buf, data []byte, length Length) []byte {
+	switch length {
+	case Fixed32:
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(data)))
+	default:
+		buf = binary.AppendUvarint(buf, uint64(len(data)))
+	}
+	return append(buf, data...)
+}
@@ -0,0 +1,81 @@
+package frame_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gratonos/gxlog/formatter"
+	"github.com/gratonos/gxlog/formatter/frame"
+	"github.com/gratonos/gxlog/formatter/msgpack"
+	"github.com/gratonos/gxlog/iface"
+)
+
+func TestRoundTrip(t *testing.T) {
+	msgs := []string{"one line", "two\nlines\n", "", strings.Repeat("x", 300)}
+	for _, length := range []frame.Length{frame.Varint, frame.Fixed32} {
+		fmtr := frame.New(frame.Config{Formatter: msgpack.New(), Length: length})
+		var stream bytes.Buffer
+		for _, msg := range msgs {
+			stream.Write(fmtr.Format(&iface.Record{Msg: msg}))
+		}
+
+		reader := frame.NewReader(&stream, length, 0)
+		for _, msg := range msgs {
+			data, err := reader.ReadFrame()
+			if err != nil {
+				t.Fatalf("TestRoundTrip: %v", err)
+			}
+			record, err := msgpack.Decode(data)
+			if err != nil {
+				t.Fatalf("TestRoundTrip: %v", err)
+			}
+			if record.Msg != msg {
+				t.Errorf("TestRoundTrip: msg: %q, expect: %q", record.Msg, msg)
+			}
+		}
+		if _, err := reader.ReadFrame(); err != io.EOF {
+			t.Errorf("TestRoundTrip: error: %v, expect: %v", err, io.EOF)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	text := formatter.Func(func(record *iface.Record) []byte {
+		return []byte(record.Msg)
+	})
+	record := &iface.Record{Msg: strings.Repeat("x", 200)}
+
+	output := frame.New(frame.Config{Formatter: text}).Format(record)
+	expect := append([]byte{0xc8, 0x01}, record.Msg...)
+	if !bytes.Equal(output, expect) {
+		t.Errorf("TestFormat: output: %q, expect: %q", output, expect)
+	}
+
+	output = frame.New(frame.Config{Formatter: text, Length: frame.Fixed32}).Format(record)
+	expect = append([]byte{0, 0, 0, 200}, record.Msg...)
+	if !bytes.Equal(output, expect) {
+		t.Errorf("TestFormat: output: %q, expect: %q", output, expect)
+	}
+}
+
+func TestReadError(t *testing.T) {
+	text := formatter.Func(func(record *iface.Record) []byte {
+		return []byte(record.Msg)
+	})
+	for _, length := range []frame.Length{frame.Varint, frame.Fixed32} {
+		data := frame.New(frame.Config{Formatter: text, Length: length}).
+			Format(&iface.Record{Msg: "hello"})
+		for i := 1; i < len(data); i++ {
+			reader := frame.NewReader(bytes.NewReader(data[:i]), length, 0)
+			if _, err := reader.ReadFrame(); err != io.ErrUnexpectedEOF {
+				t.Errorf("TestReadError: error: %v, expect: %v", err, io.ErrUnexpectedEOF)
+			}
+		}
+		reader := frame.NewReader(bytes.NewReader(data), length, 4)
+		if _, err := reader.ReadFrame(); err != frame.ErrTooLarge {
+			t.Errorf("TestReadError: error: %v, expect: %v", err, frame.ErrTooLarge)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package frame
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxSize is the default max size of a frame of a Reader.
+const DefaultMaxSize = 16 << 20
+
+// ErrTooLarge is returned by Reader.ReadFrame if the size of a frame exceeds
+// the max size. The stream is NOT recoverable after it.
+var ErrTooLarge = errors.New("frame: frame too large")
+
+// A Reader splits a stream of frames, e.g. the logs of a Formatter read
+// from a writer/usock, into frames.
+type Reader struct {
+	reader  *bufio.Reader
+	length  Length
+	maxSize int
+	buf     []byte
+}
+
+// NewReader returns a Reader with the encoding of the length prefixes. The
+// maxSize is DefaultMaxSize if it is NOT positive.
+func NewReader(reader io.Reader, length Length, maxSize int) *Reader {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	return &Reader{
+		reader:  bufio.NewReader(reader),
+		length:  length,
+		maxSize: maxSize,
+	}
+}
+
+// ReadFrame returns the next frame without the length prefix. The frame is
+// only valid until the next call of ReadFrame. It returns io.EOF if the
+// stream ends between frames, or io.ErrUnexpectedEOF within a frame.
+func (this *Reader) ReadFrame() ([]byte, error) {
+	size, err := this.readSize()
+	if err != nil {
+		return nil, err
+	}
+	if size > uint64(this.maxSize) {
+		return nil, ErrTooLarge
+	}
+	if cap(this.buf) < int(size) {
+		this.buf = make([]byte, size)
+	}
+	frame := this.buf[:size]
+	if _, err := io.ReadFull(this.reader, frame); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return frame, nil
+}
+
+func (this *Reader) readSize() (uint64, error) {
+	switch this.length {
+	case Varint:
+		// it returns io.EOF only if no byte is read
+		return binary.ReadUvarint(this.reader)
+	case Fixed32:
+		var prefix [4]byte
+		if _, err := io.ReadFull(this.reader, prefix[:]); err != nil {
+			return 0, err
+		}
+		return uint64(binary.BigEndian.Uint32(prefix[:])), nil
+	default:
+		return 0, fmt.Errorf("frame: invalid length %d", this.length)
+	}
+}
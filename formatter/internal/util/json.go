@@ -0,0 +1,109 @@
+package util
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gratonos/gxlog/iface"
+)
+
+// AppendJSONObject appends the contexts as a JSON object.
+func AppendJSONObject(buf []byte, contexts []iface.Context) []byte {
+	buf = append(buf, "{"...)
+	for i, context := range contexts {
+		if i > 0 {
+			buf = append(buf, ","...)
+		}
+		buf = AppendJSONString(buf, context.Key)
+		buf = append(buf, ":"...)
+		buf = AppendJSONValue(buf, context.Value)
+	}
+	return append(buf, "}"...)
+}
+
+// AppendJSONValue appends the value. Numbers and booleans are rendered
+// natively, durations as integers of nanoseconds and objects as JSON objects.
+func AppendJSONValue(buf []byte, value iface.Value) []byte {
+	switch value.Kind() {
+	case iface.KindString:
+		return AppendJSONString(buf, value.Str())
+	case iface.KindInt64:
+		return strconv.AppendInt(buf, value.Int64(), 10)
+	case iface.KindUint64:
+		return strconv.AppendUint(buf, value.Uint64(), 10)
+	case iface.KindFloat64:
+		f := value.Float64()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return AppendJSONString(buf, value.String())
+		}
+		return strconv.AppendFloat(buf, f, 'g', -1, 64)
+	case iface.KindBool:
+		return strconv.AppendBool(buf, value.Bool())
+	case iface.KindDuration:
+		return strconv.AppendInt(buf, int64(value.Duration()), 10)
+	case iface.KindTime:
+		buf = append(buf, `"`...)
+		buf = value.Time().AppendFormat(buf, time.RFC3339Nano)
+		return append(buf, `"`...)
+	case iface.KindObject:
+		return AppendJSONObject(buf, value.Object())
+	case iface.KindAny:
+		// reflection is only used for values of unknown types
+		bs, err := value.MarshalJSON()
+		if err == nil {
+			return append(buf, bs...)
+		}
+		return AppendJSONString(buf, value.String())
+	default:
+		return AppendJSONString(buf, value.String())
+	}
+}
+
+// AppendJSONString appends the string quoted and escaped.
+func AppendJSONString(buf []byte, str string) []byte {
+	buf = append(buf, `"`...)
+	buf = escape(buf, str)
+	return append(buf, `"`...)
+}
+
+const ctrlCharCount = 0x20
+
+var escapeMapArray [ctrlCharCount]string
+
+func init() {
+	for i := 0; i < ctrlCharCount; i++ {
+		escapeMapArray[i] = fmt.Sprintf(`\u00%02x`, i)
+	}
+}
+
+func escape(buf []byte, str string) []byte {
+	for i := 0; i < len(str); i++ {
+		b := str[i]
+		if b < ctrlCharCount {
+			switch b {
+			case '\n':
+				buf = append(buf, `\n`...)
+			case '\r':
+				buf = append(buf, `\r`...)
+			case '\t':
+				buf = append(buf, `\t`...)
+			default:
+				buf = append(buf, escapeMapArray[b]...)
+			}
+		} else {
+			switch b {
+			case '"':
+				buf = append(buf, `\"`...)
+			case '\\':
+				buf = append(buf, `\\`...)
+			case '\u007f': // DEL
+				// noop
+			default:
+				buf = append(buf, b)
+			}
+		}
+	}
+	return buf
+}
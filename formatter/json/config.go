@@ -1,7 +1,92 @@
 package json
 
+import (
+	"time"
+)
+
+// A Field is a field of a Record.
+type Field int
+
+const (
+	FieldTime Field = iota
+	FieldLevel
+	FieldFile
+	FieldLine
+	FieldPkg
+	FieldFunc
+	FieldMsg
+	FieldStack
+	FieldPrefix
+	FieldContexts
+	FieldMark
+	fieldCount
+)
+
+var fieldNames = []string{
+	FieldTime:     "Time",
+	FieldLevel:    "Level",
+	FieldFile:     "File",
+	FieldLine:     "Line",
+	FieldPkg:      "Pkg",
+	FieldFunc:     "Func",
+	FieldMsg:      "Msg",
+	FieldStack:    "Stack",
+	FieldPrefix:   "Prefix",
+	FieldContexts: "Contexts",
+	FieldMark:     "Mark",
+}
+
+// The special time layouts that render the time as an integer of the Unix
+// time.
+const (
+	UnixMilli = "unixmilli"
+	UnixNano  = "unixnano"
+)
+
 type Config struct {
 	FileSegs int
 	PkgSegs  int
 	FuncSegs int
+
+	// Fields are the fields to format in order. The default is all of the
+	// fields in the order of their definitions.
+	Fields []Field
+	// Keys maps the fields to their keys, e.g. {FieldTime: "ts"}. The default
+	// key of a field is its name, e.g. "Time".
+	Keys map[Field]string
+	// OmitEmpty omits the fields of empty strings, zero numbers, zero times,
+	// empty contexts and false marks. The level is never omitted, and the
+	// stack is always omitted if it is empty.
+	OmitEmpty bool
+	// LevelName renders the level as its name, e.g. "info", instead of an
+	// integer.
+	LevelName bool
+	// TimeLayout is the layout of the time package, UnixMilli or UnixNano.
+	// The default is time.RFC3339Nano.
+	TimeLayout string
+	// FlatContexts renders the contexts as an object, e.g. {"k1": "v1"},
+	// instead of an array of {"Key": "k1", "Value": "v1"}.
+	FlatContexts bool
+}
+
+func (this *Config) SetDefaults() {
+	if len(this.Fields) == 0 {
+		this.Fields = make([]Field, fieldCount)
+		for i := range this.Fields {
+			this.Fields[i] = Field(i)
+		}
+	}
+	keys := make(map[Field]string, fieldCount)
+	for field, name := range fieldNames {
+		keys[Field(field)] = name
+	}
+	for field, key := range this.Keys {
+		if key != "" {
+			keys[field] = key
+		}
+	}
+	this.Keys = keys
+	if this.TimeLayout == "" {
+		this.TimeLayout = time.RFC3339Nano
+	}
 }
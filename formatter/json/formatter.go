@@ -15,15 +15,37 @@ type Formatter struct {
 	pkgSegs  int
 	funcSegs int
 
+	fields       []Field
+	keys         [fieldCount][]byte // "key":
+	omitEmpty    bool
+	levelName    bool
+	timeLayout   string
+	flatContexts bool
+
 	buf  []byte
 	lock sync.Mutex
 }
 
 func New(config Config) *Formatter {
+	config.SetDefaults()
 	formatter := &Formatter{
-		fileSegs: config.FileSegs,
-		pkgSegs:  config.PkgSegs,
-		funcSegs: config.FuncSegs,
+		fileSegs:     config.FileSegs,
+		pkgSegs:      config.PkgSegs,
+		funcSegs:     config.FuncSegs,
+		omitEmpty:    config.OmitEmpty,
+		levelName:    config.LevelName,
+		timeLayout:   config.TimeLayout,
+		flatContexts: config.FlatContexts,
+	}
+	for _, field := range config.Fields {
+		if field >= 0 && field < fieldCount {
+			formatter.fields = append(formatter.fields, field)
+		}
+	}
+	for field, key := range config.Keys {
+		if field >= 0 && field < fieldCount {
+			formatter.keys[field] = append(util.AppendJSONString(nil, key), ':')
+		}
 	}
 	return formatter
 }
@@ -33,26 +55,17 @@ func (this *Formatter) Format(record *iface.Record) []byte {
 
 	buf := this.buf[:0]
 	buf = append(buf, "{"...)
-
-	buf = formatStrField(buf, "", "Time", record.Time.Format(time.RFC3339Nano), false)
-	buf = formatIntField(buf, ",", "Level", int(record.Level))
-	file := util.LastSegments(record.File, this.fileSegs, '/')
-	// avoid to omit the root path '/'
-	if len(file)+1 == len(record.File) {
-		file = record.File
+	for _, field := range this.fields {
+		// the stack is captured only for some levels, see logger.SetStackLevel
+		if (this.omitEmpty || field == FieldStack) && isEmpty(field, record) {
+			continue
+		}
+		if len(buf) > 1 {
+			buf = append(buf, ","...)
+		}
+		buf = append(buf, this.keys[field]...)
+		buf = this.formatField(buf, field, record)
 	}
-	buf = formatStrField(buf, ",", "File", file, true)
-	buf = formatIntField(buf, ",", "Line", record.Line)
-	pkg := util.LastSegments(record.Pkg, this.pkgSegs, '/')
-	buf = formatStrField(buf, ",", "Pkg", pkg, false)
-	fn := util.LastSegments(record.Func, this.funcSegs, '.')
-	buf = formatStrField(buf, ",", "Func", fn, false)
-	buf = formatStrField(buf, ",", "Msg", record.Msg, true)
-
-	buf = formatStrField(buf, ",", "Prefix", record.Prefix, true)
-	buf = formatContexts(buf, record.Contexts)
-	buf = formatBoolField(buf, ",", "Mark", record.Mark)
-
 	buf = append(buf, "}\n"...)
 	this.buf = buf
 
@@ -103,49 +116,103 @@ func (this *Formatter) SetFuncSegs(segs int) {
 	this.funcSegs = segs
 }
 
-func formatContexts(buf []byte, contexts []iface.Context) []byte {
-	buf = append(buf, `,"Contexts":[`...)
-	sep := ""
-	for _, context := range contexts {
-		buf = append(buf, sep...)
-		buf = append(buf, "{"...)
-		buf = formatStrField(buf, "", "Key", context.Key, true)
-		buf = formatStrField(buf, ",", "Value", context.Value, true)
-		buf = append(buf, "}"...)
-		sep = ","
+func (this *Formatter) formatField(buf []byte, field Field, record *iface.Record) []byte {
+	switch field {
+	case FieldTime:
+		switch this.timeLayout {
+		case UnixMilli:
+			return strconv.AppendInt(buf, record.Time.UnixMilli(), 10)
+		case UnixNano:
+			return strconv.AppendInt(buf, record.Time.UnixNano(), 10)
+		case time.RFC3339Nano:
+			buf = append(buf, `"`...)
+			buf = record.Time.AppendFormat(buf, time.RFC3339Nano)
+			return append(buf, `"`...)
+		default:
+			return util.AppendJSONString(buf, record.Time.Format(this.timeLayout))
+		}
+	case FieldLevel:
+		if this.levelName {
+			return util.AppendJSONString(buf, record.Level.String())
+		}
+		return strconv.AppendInt(buf, int64(record.Level), 10)
+	case FieldFile:
+		file := util.LastSegments(record.File, this.fileSegs, '/')
+		// avoid to omit the root path '/'
+		if len(file)+1 == len(record.File) {
+			file = record.File
+		}
+		return util.AppendJSONString(buf, file)
+	case FieldLine:
+		return strconv.AppendInt(buf, int64(record.Line), 10)
+	case FieldPkg:
+		return formatName(buf, util.LastSegments(record.Pkg, this.pkgSegs, '/'))
+	case FieldFunc:
+		return formatName(buf, util.LastSegments(record.Func, this.funcSegs, '.'))
+	case FieldMsg:
+		return util.AppendJSONString(buf, record.Msg)
+	case FieldStack:
+		return util.AppendJSONString(buf, record.Stack)
+	case FieldPrefix:
+		return util.AppendJSONString(buf, record.Prefix)
+	case FieldContexts:
+		if this.flatContexts {
+			return util.AppendJSONObject(buf, record.Contexts)
+		}
+		return formatContexts(buf, record.Contexts)
+	case FieldMark:
+		return strconv.AppendBool(buf, record.Mark)
+	default:
+		return append(buf, "null"...)
 	}
-	return append(buf, "]"...)
 }
 
-func formatStrField(buf []byte, sep, key, value string, esc bool) []byte {
-	buf = append(buf, sep...)
-	buf = append(buf, `"`...)
-	buf = append(buf, key...)
-	buf = append(buf, `":"`...)
-	if esc {
-		buf = escape(buf, value)
-	} else {
-		buf = append(buf, value...)
+func isEmpty(field Field, record *iface.Record) bool {
+	switch field {
+	case FieldTime:
+		return record.Time.IsZero()
+	case FieldFile:
+		return record.File == ""
+	case FieldLine:
+		return record.Line == 0
+	case FieldPkg:
+		return record.Pkg == ""
+	case FieldFunc:
+		return record.Func == ""
+	case FieldMsg:
+		return record.Msg == ""
+	case FieldStack:
+		return record.Stack == ""
+	case FieldPrefix:
+		return record.Prefix == ""
+	case FieldContexts:
+		return len(record.Contexts) == 0
+	case FieldMark:
+		return !record.Mark
+	default:
+		return false
 	}
-	return append(buf, `"`...)
 }
 
-func formatIntField(buf []byte, sep, key string, value int) []byte {
-	buf = append(buf, sep...)
-	buf = append(buf, `"`...)
-	buf = append(buf, key...)
-	buf = append(buf, `":`...)
-	return strconv.AppendInt(buf, int64(value), 10)
+func formatContexts(buf []byte, contexts []iface.Context) []byte {
+	buf = append(buf, "["...)
+	for i, context := range contexts {
+		if i > 0 {
+			buf = append(buf, ","...)
+		}
+		buf = append(buf, `{"Key":`...)
+		buf = util.AppendJSONString(buf, context.Key)
+		buf = append(buf, `,"Value":`...)
+		buf = util.AppendJSONValue(buf, context.Value)
+		buf = append(buf, "}"...)
+	}
+	return append(buf, "]"...)
 }
 
-func formatBoolField(buf []byte, sep, key string, value bool) []byte {
-	buf = append(buf, sep...)
+// formatName formats the names of packages and functions, which need NOT
+// escaping.
+func formatName(buf []byte, name string) []byte {
 	buf = append(buf, `"`...)
-	buf = append(buf, key...)
-	buf = append(buf, `":`...)
-	if value {
-		return append(buf, "true"...)
-	} else {
-		return append(buf, "false"...)
-	}
+	buf = append(buf, name...)
+	return append(buf, `"`...)
 }
@@ -3,6 +3,8 @@ package json_test
 import (
 	"bytes"
 	ejson "encoding/json"
+	"errors"
+	"strconv"
 	"testing"
 	"time"
 
@@ -21,6 +23,7 @@ const (
 	tmplPkg         = "github.com/gratonos/gxlog"
 	tmplFunc        = "Test"
 	tmplMsg         = "testing"
+	tmplStack       = "main.main()\n\t/home/test/main.go:10\n"
 	tmplPrefix      = "**** "
 	tmplContextPair = "(k1: v1) (k2: v2)"
 	tmplContextList = "k1: v1, k2: v2"
@@ -46,6 +49,37 @@ func TestFull(t *testing.T) {
 	}
 }
 
+func TestEmptyStack(t *testing.T) {
+	formatter := json.New(json.Config{})
+	record := tmplRecord()
+	record.Stack = ""
+	output := formatter.Format(record)
+	if bytes.Contains(output, []byte(`"Stack"`)) {
+		t.Errorf("TestEmptyStack: output: %q", output)
+	}
+}
+
+func TestTypedContexts(t *testing.T) {
+	formatter := json.New(json.Config{})
+	record := tmplRecord()
+	record.Contexts = []iface.Context{
+		iface.Int("int", -1),
+		iface.Uint64("uint", 2),
+		iface.Float64("float", 1.5),
+		iface.Bool("bool", true),
+		iface.Duration("duration", time.Second),
+		iface.Time("time", tmplTimestamp),
+		iface.Err("error", errors.New("an \"error\"")),
+		iface.Object("object", iface.String("k", "v"), iface.Int("n", 1)),
+		iface.Any("any", []int{1, 2}),
+	}
+	expect := jsonMarshal(record)
+	output := formatter.Format(record)
+	if !bytes.Equal(expect, output) {
+		t.Errorf("TestTypedContexts:\noutput: %q\nexpect: %q", output, expect)
+	}
+}
+
 func jsonMarshal(record *iface.Record) []byte {
 	bs, err := ejson.Marshal(record)
 	if err != nil {
@@ -63,11 +97,50 @@ func tmplRecord() *iface.Record {
 		Pkg:    tmplPkg,
 		Func:   tmplFunc,
 		Msg:    tmplMsg,
+		Stack:  tmplStack,
 		Prefix: tmplPrefix,
 		Contexts: []iface.Context{
-			{Key: "k1", Value: "v1"},
-			{Key: "k2", Value: "v2"},
+			iface.String("k1", "v1"),
+			iface.String("k2", "v2"),
 		},
 		Mark: true,
 	}
 }
+
+func TestConfig(t *testing.T) {
+	formatter := json.New(json.Config{
+		FileSegs: 1,
+		Fields: []json.Field{json.FieldTime, json.FieldLevel, json.FieldMsg,
+			json.FieldFile, json.FieldLine, json.FieldPrefix, json.FieldContexts, json.FieldMark},
+		Keys: map[json.Field]string{
+			json.FieldTime:  "ts",
+			json.FieldLevel: "severity",
+			json.FieldMsg:   "message",
+		},
+		OmitEmpty:    true,
+		LevelName:    true,
+		TimeLayout:   json.UnixMilli,
+		FlatContexts: true,
+	})
+	record := tmplRecord()
+	record.Prefix = ""
+	record.Contexts = append(record.Contexts, iface.Object("obj", iface.Int("n", 1)))
+	record.Mark = false
+	expect := `{"ts":` + strconv.FormatInt(tmplTimestamp.UnixMilli(), 10) +
+		`,"severity":"info","message":"testing","File":"logger.go","Line":64,` +
+		`"Contexts":{"k1":"v1","k2":"v2","obj":{"n":1}}}` + "\n"
+	output := formatter.Format(record)
+	if string(output) != expect {
+		t.Errorf("TestConfig:\noutput: %q\nexpect: %q", output, expect)
+	}
+
+	formatter = json.New(json.Config{
+		Fields:     []json.Field{json.FieldTime, json.FieldContexts},
+		TimeLayout: `"2006-01-02"`,
+	})
+	record.Contexts = nil
+	expect = `{"Time":"\"` + tmplDate + `\"","Contexts":[]}` + "\n"
+	if output := formatter.Format(record); string(output) != expect {
+		t.Errorf("TestConfig:\noutput: %q\nexpect: %q", output, expect)
+	}
+}
@@ -0,0 +1,57 @@
+package logfmt
+
+// The default keys of the fields.
+const (
+	DefaultTimeKey   = "time"
+	DefaultLevelKey  = "level"
+	DefaultFileKey   = "file"
+	DefaultLineKey   = "line"
+	DefaultPkgKey    = "pkg"
+	DefaultFuncKey   = "func"
+	DefaultMsgKey    = "msg"
+	DefaultStackKey  = "stack"
+	DefaultPrefixKey = "prefix"
+	DefaultMarkKey   = "mark"
+)
+
+// OmitKey omits a field if it is used as the key of the field.
+const OmitKey = "-"
+
+type Config struct {
+	// The keys of the fields, which default to the Default*Key ones. Use
+	// OmitKey to omit a field. The stack and the prefix are omitted if they
+	// are empty, and the mark is omitted unless it is true.
+	TimeKey   string
+	LevelKey  string
+	FileKey   string
+	LineKey   string
+	PkgKey    string
+	FuncKey   string
+	MsgKey    string
+	StackKey  string
+	PrefixKey string
+	MarkKey   string
+
+	FileSegs int
+	PkgSegs  int
+	FuncSegs int
+}
+
+func (this *Config) SetDefaults() {
+	setDefault(&this.TimeKey, DefaultTimeKey)
+	setDefault(&this.LevelKey, DefaultLevelKey)
+	setDefault(&this.FileKey, DefaultFileKey)
+	setDefault(&this.LineKey, DefaultLineKey)
+	setDefault(&this.PkgKey, DefaultPkgKey)
+	setDefault(&this.FuncKey, DefaultFuncKey)
+	setDefault(&this.MsgKey, DefaultMsgKey)
+	setDefault(&this.StackKey, DefaultStackKey)
+	setDefault(&this.PrefixKey, DefaultPrefixKey)
+	setDefault(&this.MarkKey, DefaultMarkKey)
+}
+
+func setDefault(key *string, value string) {
+	if *key == "" {
+		*key = value
+	}
+}
@@ -0,0 +1,226 @@
+// Package logfmt implements a logfmt formatter, e.g.
+//
+//	time=2026-10-18T10:15:00.123456+08:00 level=info file=main.go line=9 pkg=main func=main msg="hello world" user=alice
+package logfmt
+
+import (
+	"strconv"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gratonos/gxlog/formatter/internal/util"
+	"github.com/gratonos/gxlog/iface"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// A Formatter formats a Record in a line of key=value pairs. The values
+// are quoted if they contain spaces, quotes, '=' or control characters. The
+// Contexts are flattened into the top-level keys, and the keys of objects
+// are joined by '.', e.g. "user.id=1".
+type Formatter struct {
+	config Config
+
+	buf  []byte
+	lock sync.Mutex
+}
+
+func New(config Config) *Formatter {
+	config.SetDefaults()
+	return &Formatter{config: config}
+}
+
+func (this *Formatter) Format(record *iface.Record) []byte {
+	this.lock.Lock()
+
+	config := &this.config
+	buf := this.buf[:0]
+
+	if config.TimeKey != OmitKey {
+		buf = appendKey(buf, config.TimeKey)
+		buf = record.Time.AppendFormat(buf, time.RFC3339Nano)
+	}
+	if config.LevelKey != OmitKey {
+		buf = appendKey(buf, config.LevelKey)
+		buf = append(buf, record.Level.String()...)
+	}
+	if config.FileKey != OmitKey {
+		file := util.LastSegments(record.File, config.FileSegs, '/')
+		// avoid to omit the root path '/'
+		if len(file)+1 == len(record.File) {
+			file = record.File
+		}
+		buf = appendStrField(buf, config.FileKey, file)
+	}
+	if config.LineKey != OmitKey {
+		buf = appendKey(buf, config.LineKey)
+		buf = strconv.AppendInt(buf, int64(record.Line), 10)
+	}
+	if config.PkgKey != OmitKey {
+		pkg := util.LastSegments(record.Pkg, config.PkgSegs, '/')
+		buf = appendStrField(buf, config.PkgKey, pkg)
+	}
+	if config.FuncKey != OmitKey {
+		fn := util.LastSegments(record.Func, config.FuncSegs, '.')
+		buf = appendStrField(buf, config.FuncKey, fn)
+	}
+	if config.MsgKey != OmitKey {
+		buf = appendStrField(buf, config.MsgKey, record.Msg)
+	}
+	if config.StackKey != OmitKey && record.Stack != "" {
+		buf = appendStrField(buf, config.StackKey, record.Stack)
+	}
+	if config.PrefixKey != OmitKey && record.Prefix != "" {
+		buf = appendStrField(buf, config.PrefixKey, record.Prefix)
+	}
+	for _, context := range record.Contexts {
+		buf = appendContext(buf, "", context)
+	}
+	if config.MarkKey != OmitKey && record.Mark {
+		buf = appendKey(buf, config.MarkKey)
+		buf = append(buf, "true"...)
+	}
+
+	buf = append(buf, '\n')
+	this.buf = buf
+
+	this.lock.Unlock()
+
+	return buf
+}
+
+func (this *Formatter) FileSegs() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.config.FileSegs
+}
+
+func (this *Formatter) SetFileSegs(segs int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.config.FileSegs = segs
+}
+
+func (this *Formatter) PkgSegs() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.config.PkgSegs
+}
+
+func (this *Formatter) SetPkgSegs(segs int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.config.PkgSegs = segs
+}
+
+func (this *Formatter) FuncSegs() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.config.FuncSegs
+}
+
+func (this *Formatter) SetFuncSegs(segs int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.config.FuncSegs = segs
+}
+
+func appendContext(buf []byte, parent string, context iface.Context) []byte {
+	key := context.Key
+	if parent != "" {
+		key = parent + "." + key
+	}
+	value := context.Value
+	switch value.Kind() {
+	case iface.KindObject:
+		for _, child := range value.Object() {
+			buf = appendContext(buf, key, child)
+		}
+		return buf
+	case iface.KindString:
+		return appendStrField(buf, key, value.Str())
+	case iface.KindInt64, iface.KindUint64, iface.KindFloat64, iface.KindBool,
+		iface.KindDuration, iface.KindTime:
+		// never quoted
+		buf = appendKey(buf, key)
+		return value.Append(buf)
+	default:
+		return appendStrField(buf, key, value.String())
+	}
+}
+
+// appendKey appends the key and '='. The invalid characters of the key are
+// replaced with '_'.
+func appendKey(buf []byte, key string) []byte {
+	if len(buf) > 0 {
+		buf = append(buf, ' ')
+	}
+	if key == "" {
+		return append(buf, "_="...)
+	}
+	for _, r := range key {
+		if needsQuoting(r) {
+			buf = append(buf, '_')
+		} else {
+			buf = utf8.AppendRune(buf, r)
+		}
+	}
+	return append(buf, '=')
+}
+
+func appendStrField(buf []byte, key, value string) []byte {
+	buf = appendKey(buf, key)
+	return appendStr(buf, value)
+}
+
+// appendStr quotes the string if it is empty or contains any character that
+// needs quoting.
+func appendStr(buf []byte, str string) []byte {
+	quoting := str == ""
+	for _, r := range str {
+		if needsQuoting(r) {
+			quoting = true
+			break
+		}
+	}
+	if !quoting {
+		return append(buf, str...)
+	}
+
+	buf = append(buf, '"')
+	for i := 0; i < len(str); {
+		r, size := utf8.DecodeRuneInString(str[i:])
+		switch {
+		case r == '"' || r == '\\':
+			buf = append(buf, '\\', byte(r))
+		case r == '\n':
+			buf = append(buf, `\n`...)
+		case r == '\r':
+			buf = append(buf, `\r`...)
+		case r == '\t':
+			buf = append(buf, `\t`...)
+		case r < ' ' || r == '\u007f':
+			buf = append(buf, `\u00`...)
+			buf = append(buf, hexDigits[r>>4], hexDigits[r&0xf])
+		case r == utf8.RuneError && size == 1:
+			buf = append(buf, "\ufffd"...)
+		default:
+			buf = append(buf, str[i:i+size]...)
+		}
+		i += size
+	}
+	return append(buf, '"')
+}
+
+func needsQuoting(r rune) bool {
+	return r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError ||
+		unicode.IsSpace(r) || !unicode.IsPrint(r)
+}
@@ -0,0 +1,96 @@
+package logfmt_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gratonos/gxlog/formatter/logfmt"
+	"github.com/gratonos/gxlog/iface"
+)
+
+var tmplTime = time.Date(2018, 8, 1, 7, 12, 7, 235605270, time.UTC)
+
+func tmplRecord() *iface.Record {
+	return &iface.Record{
+		Time:   tmplTime,
+		Level:  iface.Info,
+		File:   "/home/test/src/github.com/gratonos/gxlog/logger.go",
+		Line:   64,
+		Pkg:    "github.com/gratonos/gxlog",
+		Func:   "logger.Test",
+		Msg:    "testing",
+		Prefix: "**** ",
+		Contexts: []iface.Context{
+			iface.String("k1", "v1"),
+			iface.String("k2", "v2"),
+		},
+		Mark: true,
+	}
+}
+
+func TestFull(t *testing.T) {
+	formatter := logfmt.New(logfmt.Config{})
+	expect := "time=2018-08-01T07:12:07.23560527Z level=info " +
+		"file=/home/test/src/github.com/gratonos/gxlog/logger.go line=64 " +
+		`pkg=github.com/gratonos/gxlog func=logger.Test msg=testing prefix="**** " ` +
+		"k1=v1 k2=v2 mark=true\n"
+	if output := string(formatter.Format(tmplRecord())); output != expect {
+		t.Errorf("TestFull:\noutput: %q\nexpect: %q", output, expect)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	formatter := logfmt.New(logfmt.Config{
+		TimeKey:   logfmt.OmitKey,
+		LevelKey:  "severity",
+		LineKey:   logfmt.OmitKey,
+		MsgKey:    "message",
+		PrefixKey: logfmt.OmitKey,
+		MarkKey:   logfmt.OmitKey,
+		FileSegs:  1,
+		PkgSegs:   1,
+		FuncSegs:  1,
+	})
+	record := tmplRecord()
+	record.Contexts = nil
+	expect := "severity=info file=logger.go pkg=gxlog func=Test message=testing\n"
+	if output := string(formatter.Format(record)); output != expect {
+		t.Errorf("TestConfig:\noutput: %q\nexpect: %q", output, expect)
+	}
+}
+
+func TestEscaping(t *testing.T) {
+	formatter := logfmt.New(logfmt.Config{
+		TimeKey:  logfmt.OmitKey,
+		LevelKey: logfmt.OmitKey,
+		FileKey:  logfmt.OmitKey,
+		LineKey:  logfmt.OmitKey,
+		PkgKey:   logfmt.OmitKey,
+		FuncKey:  logfmt.OmitKey,
+	})
+	record := &iface.Record{
+		Msg:   "a \"quoted\"\nline\t\\ \x01\xff",
+		Stack: "main.main()\n",
+		Contexts: []iface.Context{
+			iface.String("empty", ""),
+			iface.String("eq", "a=b"),
+			iface.String("bad key", "ünïcode"),
+			iface.Int("int", -1),
+			iface.Float64("float", 1.5),
+			iface.Bool("bool", true),
+			iface.Duration("duration", 1500*time.Millisecond),
+			iface.Time("time", tmplTime),
+			iface.Err("error", errors.New("an error")),
+			iface.Object("user", iface.Int("id", 1), iface.Object("name", iface.String("first", "A"))),
+			iface.Any("any", []int{1, 2}),
+		},
+	}
+	expect := `msg="a \"quoted\"\nline\t\\ \u0001` + "\ufffd" + `" stack="main.main()\n" ` +
+		`empty="" eq="a=b" bad_key=ünïcode int=-1 float=1.5 bool=true duration=1.5s ` +
+		`time=2018-08-01T07:12:07.23560527Z error="an error" user.id=1 user.name.first=A ` +
+		`any="[1 2]"` + "\n"
+	if output := string(formatter.Format(record)); output != expect {
+		t.Errorf("TestEscaping:\noutput: %q\nexpect: %q", output, expect)
+	}
+}
@@ -0,0 +1,462 @@
+package msgpack
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/gratonos/gxlog/iface"
+)
+
+var errShortData = errors.New("unexpected end of data")
+
+// Decode decodes a Record from the data, which MUST be exactly one output of
+// a Formatter. Unknown fields are skipped. The times are in the local time
+// zone. The values of errors are reconstructed by errors.New, and the values
+// of KindAny are decoded as strings.
+func Decode(data []byte) (*iface.Record, error) {
+	decoder := &decoder{data: data}
+	record, err := decoder.decodeRecord()
+	if err == nil && decoder.pos != len(data) {
+		err = fmt.Errorf("%d bytes of trailing data", len(data)-decoder.pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("msgpack.Decode: %v", err)
+	}
+	return record, nil
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (this *decoder) decodeRecord() (*iface.Record, error) {
+	n, err := this.readMapHeader()
+	if err != nil {
+		return nil, err
+	}
+	record := &iface.Record{}
+	for i := 0; i < n; i++ {
+		key, err := this.readStr()
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case keyTime:
+			record.Time, err = this.readTime()
+		case keyLevel:
+			var level int64
+			level, err = this.readInt()
+			record.Level = iface.Level(level)
+		case keyFile:
+			record.File, err = this.readStr()
+		case keyLine:
+			var line int64
+			line, err = this.readInt()
+			record.Line = int(line)
+		case keyPkg:
+			record.Pkg, err = this.readStr()
+		case keyFunc:
+			record.Func, err = this.readStr()
+		case keyMsg:
+			record.Msg, err = this.readStr()
+		case keyStack:
+			record.Stack, err = this.readStr()
+		case keyPrefix:
+			record.Prefix, err = this.readStr()
+		case keyContexts:
+			record.Contexts, err = this.readContexts()
+		case keyMark:
+			record.Mark, err = this.readBool()
+		default:
+			err = this.skip()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+	}
+	return record, nil
+}
+
+func (this *decoder) readContexts() ([]iface.Context, error) {
+	n, err := this.readArrayHeader()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	contexts := make([]iface.Context, 0, n)
+	for i := 0; i < n; i++ {
+		m, err := this.readArrayHeader()
+		if err != nil {
+			return nil, err
+		}
+		if m != 3 {
+			return nil, fmt.Errorf("invalid context of %d elements", m)
+		}
+		key, err := this.readStr()
+		if err != nil {
+			return nil, err
+		}
+		kind, err := this.readUint()
+		if err != nil {
+			return nil, err
+		}
+		value, err := this.readValue(iface.Kind(kind))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+		contexts = append(contexts, iface.Context{Key: key, Value: value})
+	}
+	return contexts, nil
+}
+
+func (this *decoder) readValue(kind iface.Kind) (iface.Value, error) {
+	var value iface.Value
+	var err error
+	switch kind {
+	case iface.KindString, iface.KindAny:
+		var str string
+		str, err = this.readStr()
+		value = iface.StringValue(str)
+	case iface.KindInt64:
+		var n int64
+		n, err = this.readInt()
+		value = iface.Int64Value(n)
+	case iface.KindUint64:
+		var n uint64
+		n, err = this.readUint()
+		value = iface.Uint64Value(n)
+	case iface.KindFloat64:
+		var f float64
+		f, err = this.readFloat()
+		value = iface.Float64Value(f)
+	case iface.KindBool:
+		var ok bool
+		ok, err = this.readBool()
+		value = iface.BoolValue(ok)
+	case iface.KindDuration:
+		var n int64
+		n, err = this.readInt()
+		value = iface.DurationValue(time.Duration(n))
+	case iface.KindTime:
+		var t time.Time
+		t, err = this.readTime()
+		value = iface.TimeValue(t)
+	case iface.KindError:
+		var str string
+		str, err = this.readStr()
+		value = iface.ErrorValue(errors.New(str))
+	case iface.KindObject:
+		var contexts []iface.Context
+		contexts, err = this.readContexts()
+		value = iface.ObjectValue(contexts...)
+	default:
+		err = fmt.Errorf("unknown kind %d", kind)
+	}
+	return value, err
+}
+
+func (this *decoder) readMapHeader() (int, error) {
+	b, err := this.readByte()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case b&0xf0 == 0x80:
+		return int(b & 0x0f), nil
+	case b == 0xde:
+		return this.readLength(2)
+	case b == 0xdf:
+		return this.readLength(4)
+	default:
+		return 0, unexpected(b, "map")
+	}
+}
+
+func (this *decoder) readArrayHeader() (int, error) {
+	b, err := this.readByte()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case b&0xf0 == 0x90:
+		return int(b & 0x0f), nil
+	case b == 0xdc:
+		return this.readLength(2)
+	case b == 0xdd:
+		return this.readLength(4)
+	default:
+		return 0, unexpected(b, "array")
+	}
+}
+
+func (this *decoder) readStr() (string, error) {
+	b, err := this.readByte()
+	if err != nil {
+		return "", err
+	}
+	var n int
+	switch {
+	case b&0xe0 == 0xa0:
+		n = int(b & 0x1f)
+	case b == 0xd9:
+		n, err = this.readLength(1)
+	case b == 0xda:
+		n, err = this.readLength(2)
+	case b == 0xdb:
+		n, err = this.readLength(4)
+	default:
+		return "", unexpected(b, "str")
+	}
+	if err != nil {
+		return "", err
+	}
+	bs, err := this.readN(n)
+	return string(bs), err
+}
+
+func (this *decoder) readInt() (int64, error) {
+	b, err := this.readByte()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case b < 0x80 || b >= 0xe0:
+		return int64(int8(b)), nil
+	case b == 0xd0:
+		n, err := this.readBigEndian(1)
+		return int64(int8(n)), err
+	case b == 0xd1:
+		n, err := this.readBigEndian(2)
+		return int64(int16(n)), err
+	case b == 0xd2:
+		n, err := this.readBigEndian(4)
+		return int64(int32(n)), err
+	case b == 0xd3:
+		n, err := this.readBigEndian(8)
+		return int64(n), err
+	case b >= 0xcc && b <= 0xcf:
+		n, err := this.readBigEndian(1 << (b - 0xcc))
+		if err == nil && n > math.MaxInt64 {
+			err = errors.New("integer overflow")
+		}
+		return int64(n), err
+	default:
+		return 0, unexpected(b, "int")
+	}
+}
+
+func (this *decoder) readUint() (uint64, error) {
+	b, err := this.readByte()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case b < 0x80:
+		return uint64(b), nil
+	case b >= 0xcc && b <= 0xcf:
+		return this.readBigEndian(1 << (b - 0xcc))
+	default:
+		return 0, unexpected(b, "uint")
+	}
+}
+
+func (this *decoder) readFloat() (float64, error) {
+	b, err := this.readByte()
+	if err != nil {
+		return 0, err
+	}
+	switch b {
+	case 0xca:
+		n, err := this.readBigEndian(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := this.readBigEndian(8)
+		return math.Float64frombits(n), err
+	default:
+		return 0, unexpected(b, "float")
+	}
+}
+
+func (this *decoder) readBool() (bool, error) {
+	b, err := this.readByte()
+	if err != nil {
+		return false, err
+	}
+	switch b {
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	default:
+		return false, unexpected(b, "bool")
+	}
+}
+
+func (this *decoder) readTime() (time.Time, error) {
+	b, err := this.readByte()
+	if err != nil {
+		return time.Time{}, err
+	}
+	var size int
+	switch b {
+	case 0xd6:
+		size = 4
+	case 0xd7:
+		size = 8
+	case 0xc7:
+		if size, err = this.readLength(1); err != nil {
+			return time.Time{}, err
+		}
+	default:
+		return time.Time{}, unexpected(b, "timestamp")
+	}
+	bs, err := this.readN(size + 1)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if int8(bs[0]) != timestampExt {
+		return time.Time{}, fmt.Errorf("unexpected extension type %d", int8(bs[0]))
+	}
+	bs = bs[1:]
+	switch size {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(bs)), 0), nil
+	case 8:
+		n := binary.BigEndian.Uint64(bs)
+		return time.Unix(int64(n&(1<<34-1)), int64(n>>34)), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(bs)
+		sec := binary.BigEndian.Uint64(bs[4:])
+		return time.Unix(int64(sec), int64(nsec)), nil
+	default:
+		return time.Time{}, fmt.Errorf("invalid timestamp of %d bytes", size)
+	}
+}
+
+// skip skips an object of any type.
+func (this *decoder) skip() error {
+	b, err := this.readByte()
+	if err != nil {
+		return err
+	}
+	var size, elems int
+	switch {
+	case b < 0x80 || b >= 0xe0, b == 0xc0, b == 0xc2, b == 0xc3:
+	case b&0xf0 == 0x80:
+		elems = int(b&0x0f) * 2
+	case b&0xf0 == 0x90:
+		elems = int(b & 0x0f)
+	case b&0xe0 == 0xa0:
+		size = int(b & 0x1f)
+	case b == 0xcc, b == 0xd0:
+		size = 1
+	case b == 0xcd, b == 0xd1, b == 0xd4:
+		size = 2 // fixext 1 has a type and 1 byte
+	case b == 0xd5:
+		size = 3
+	case b == 0xca, b == 0xce, b == 0xd2:
+		size = 4
+	case b == 0xd6:
+		size = 5
+	case b == 0xcb, b == 0xcf, b == 0xd3:
+		size = 8
+	case b == 0xd7:
+		size = 9
+	case b == 0xd8:
+		size = 17
+	case b == 0xc4, b == 0xd9:
+		size, err = this.readLength(1)
+	case b == 0xc5, b == 0xda:
+		size, err = this.readLength(2)
+	case b == 0xc6, b == 0xdb:
+		size, err = this.readLength(4)
+	case b == 0xc7:
+		size, err = this.readLength(1)
+		size++
+	case b == 0xc8:
+		size, err = this.readLength(2)
+		size++
+	case b == 0xc9:
+		size, err = this.readLength(4)
+		size++
+	case b == 0xdc:
+		elems, err = this.readLength(2)
+	case b == 0xdd:
+		elems, err = this.readLength(4)
+	case b == 0xde:
+		elems, err = this.readLength(2)
+		elems *= 2
+	case b == 0xdf:
+		elems, err = this.readLength(4)
+		elems *= 2
+	default:
+		return unexpected(b, "object")
+	}
+	if err != nil {
+		return err
+	}
+	if _, err = this.readN(size); err != nil {
+		return err
+	}
+	for i := 0; i < elems; i++ {
+		if err = this.skip(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readLength reads the length of a string, a binary, an array or a map, which
+// is bounded by the remaining data to avoid a huge allocation.
+func (this *decoder) readLength(n int) (int, error) {
+	length, err := this.readBigEndian(n)
+	if err != nil {
+		return 0, err
+	}
+	// every byte or element takes at least one byte
+	if length > uint64(len(this.data)-this.pos) {
+		return 0, errShortData
+	}
+	return int(length), nil
+}
+
+// readBigEndian reads an unsigned integer of n bytes in big-endian.
+func (this *decoder) readBigEndian(n int) (uint64, error) {
+	bs, err := this.readN(n)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, b := range bs {
+		u = u<<8 | uint64(b)
+	}
+	return u, nil
+}
+
+func (this *decoder) readByte() (byte, error) {
+	if this.pos >= len(this.data) {
+		return 0, errShortData
+	}
+	b := this.data[this.pos]
+	this.pos++
+	return b, nil
+}
+
+func (this *decoder) readN(n int) ([]byte, error) {
+	if n < 0 || n > len(this.data)-this.pos {
+		return nil, errShortData
+	}
+	bs := this.data[this.pos : this.pos+n]
+	this.pos += n
+	return bs, nil
+}
+
+func unexpected(b byte, expect string) error {
+	return fmt.Errorf("expect %s, got type 0x%02x", expect, b)
+}
@@ -0,0 +1,202 @@
+// Package msgpack implements a MessagePack formatter and its decoder.
+//
+// A Record is encoded as a map from the field names, e.g. "Time" and
+// "Level", to the values. The Time is a timestamp extension with the
+// nanoseconds. A Context is encoded as an array of its key, its kind and its
+// value. The values of objects are arrays of contexts, the values of times
+// are timestamps, the values of durations are nanoseconds, and the values of
+// errors and the others of KindAny are strings.
+package msgpack
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gratonos/gxlog/iface"
+)
+
+// The field names of a Record.
+const (
+	keyTime     = "Time"
+	keyLevel    = "Level"
+	keyFile     = "File"
+	keyLine     = "Line"
+	keyPkg      = "Pkg"
+	keyFunc     = "Func"
+	keyMsg      = "Msg"
+	keyStack    = "Stack"
+	keyPrefix   = "Prefix"
+	keyContexts = "Contexts"
+	keyMark     = "Mark"
+	fieldCount  = 11
+)
+
+const timestampExt = -1
+
+type Formatter struct {
+	buf  []byte
+	lock sync.Mutex
+}
+
+func New() *Formatter {
+	return &Formatter{}
+}
+
+func (this *Formatter) Format(record *iface.Record) []byte {
+	this.lock.Lock()
+
+	buf := this.buf[:0]
+	buf = appendMapHeader(buf, fieldCount)
+	buf = appendStr(buf, keyTime)
+	buf = appendTime(buf, record.Time)
+	buf = appendStr(buf, keyLevel)
+	buf = appendInt(buf, int64(record.Level))
+	buf = appendStr(buf, keyFile)
+	buf = appendStr(buf, record.File)
+	buf = appendStr(buf, keyLine)
+	buf = appendInt(buf, int64(record.Line))
+	buf = appendStr(buf, keyPkg)
+	buf = appendStr(buf, record.Pkg)
+	buf = appendStr(buf, keyFunc)
+	buf = appendStr(buf, record.Func)
+	buf = appendStr(buf, keyMsg)
+	buf = appendStr(buf, record.Msg)
+	buf = appendStr(buf, keyStack)
+	buf = appendStr(buf, record.Stack)
+	buf = appendStr(buf, keyPrefix)
+	buf = appendStr(buf, record.Prefix)
+	buf = appendStr(buf, keyContexts)
+	buf = appendContexts(buf, record.Contexts)
+	buf = appendStr(buf, keyMark)
+	buf = appendBool(buf, record.Mark)
+	this.buf = buf
+
+	this.lock.Unlock()
+
+	return buf
+}
+
+func appendContexts(buf []byte, contexts []iface.Context) []byte {
+	buf = appendArrayHeader(buf, len(contexts))
+	for _, context := range contexts {
+		buf = appendArrayHeader(buf, 3)
+		buf = appendStr(buf, context.Key)
+		buf = appendUint(buf, uint64(context.Value.Kind()))
+		buf = appendValue(buf, context.Value)
+	}
+	return buf
+}
+
+func appendValue(buf []byte, value iface.Value) []byte {
+	switch value.Kind() {
+	case iface.KindString:
+		return appendStr(buf, value.Str())
+	case iface.KindInt64:
+		return appendInt(buf, value.Int64())
+	case iface.KindUint64:
+		return appendUint(buf, value.Uint64())
+	case iface.KindFloat64:
+		buf = append(buf, 0xcb)
+		return binary.BigEndian.AppendUint64(buf, math.Float64bits(value.Float64()))
+	case iface.KindBool:
+		return appendBool(buf, value.Bool())
+	case iface.KindDuration:
+		return appendInt(buf, int64(value.Duration()))
+	case iface.KindTime:
+		return appendTime(buf, value.Time())
+	case iface.KindObject:
+		return appendContexts(buf, value.Object())
+	default:
+		return appendStr(buf, value.String())
+	}
+}
+
+func appendMapHeader(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xde), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, 0xdf), uint32(n))
+	}
+}
+
+func appendArrayHeader(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xdc), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, 0xdd), uint32(n))
+	}
+}
+
+func appendStr(buf []byte, str string) []byte {
+	n := len(str)
+	switch {
+	case n < 32:
+		buf = append(buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		buf = binary.BigEndian.AppendUint16(append(buf, 0xda), uint16(n))
+	default:
+		buf = binary.BigEndian.AppendUint32(append(buf, 0xdb), uint32(n))
+	}
+	return append(buf, str...)
+}
+
+func appendInt(buf []byte, n int64) []byte {
+	switch {
+	case n >= 0:
+		return appendUint(buf, uint64(n))
+	case n >= -32:
+		return append(buf, byte(n)) // negative fixint
+	case n >= math.MinInt8:
+		return append(buf, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xd2), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(n))
+	}
+}
+
+func appendUint(buf []byte, n uint64) []byte {
+	switch {
+	case n < 128:
+		return append(buf, byte(n)) // positive fixint
+	case n <= math.MaxUint8:
+		return append(buf, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xcd), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xce), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, 0xcf), n)
+	}
+}
+
+func appendBool(buf []byte, ok bool) []byte {
+	if ok {
+		return append(buf, 0xc3)
+	}
+	return append(buf, 0xc2)
+}
+
+// appendTime appends a timestamp 64 if possible, or a timestamp 96.
+func appendTime(buf []byte, t time.Time) []byte {
+	sec, nsec := t.Unix(), uint64(t.Nanosecond())
+	if sec>>34 == 0 {
+		buf = append(buf, 0xd7, byte(timestampExt&0xff))
+		return binary.BigEndian.AppendUint64(buf, nsec<<34|uint64(sec))
+	}
+	buf = append(buf, 0xc7, 12, byte(timestampExt&0xff))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(nsec))
+	return binary.BigEndian.AppendUint64(buf, uint64(sec))
+}
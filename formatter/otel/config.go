@@ -0,0 +1,7 @@
+package otel
+
+type Config struct {
+	FileSegs int
+	PkgSegs  int
+	FuncSegs int
+}
@@ -0,0 +1,242 @@
+// Package otel implements a json formatter of the OpenTelemetry log data
+// model, which renders a Record as a LogRecord of OTLP/JSON.
+package otel
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gratonos/gxlog/formatter/internal/util"
+	"github.com/gratonos/gxlog/iface"
+)
+
+var severityNumbers = []int{
+	iface.Trace: 1,
+	iface.Debug: 5,
+	iface.Info:  9,
+	iface.Warn:  13,
+	iface.Error: 17,
+	iface.Fatal: 21,
+}
+
+var severityTexts = []string{
+	iface.Trace: "TRACE",
+	iface.Debug: "DEBUG",
+	iface.Info:  "INFO",
+	iface.Warn:  "WARN",
+	iface.Error: "ERROR",
+	iface.Fatal: "FATAL",
+}
+
+// SeverityNumber returns the SeverityNumber of the level, which is the
+// smallest one of the range of the level, e.g. 9 (INFO) for Info.
+func SeverityNumber(level iface.Level) int {
+	if level < iface.Trace || level > iface.Fatal {
+		return 0 // UNSPECIFIED
+	}
+	return severityNumbers[level]
+}
+
+// SeverityText returns the short name of the level, e.g. "INFO".
+func SeverityText(level iface.Level) string {
+	if level < iface.Trace || level > iface.Fatal {
+		return level.String()
+	}
+	return severityTexts[level]
+}
+
+// A Formatter maps the fields of a Record onto the log data model as
+// follows:
+//
+//	Time     -> timeUnixNano
+//	Level    -> severityNumber and severityText
+//	Msg      -> body
+//	File     -> attributes["code.filepath"]
+//	Line     -> attributes["code.lineno"]
+//	Pkg      -> attributes["code.namespace"]
+//	Func     -> attributes["code.function"]
+//	Stack    -> attributes["code.stacktrace"]
+//	Prefix   -> attributes["gxlog.prefix"]
+//	Mark     -> attributes["gxlog.mark"]
+//	Contexts -> the other attributes
+//
+// The empty ones of the Stack, Prefix and Mark are omitted.
+type Formatter struct {
+	fileSegs int
+	pkgSegs  int
+	funcSegs int
+
+	buf  []byte
+	lock sync.Mutex
+}
+
+func New(config Config) *Formatter {
+	return &Formatter{
+		fileSegs: config.FileSegs,
+		pkgSegs:  config.PkgSegs,
+		funcSegs: config.FuncSegs,
+	}
+}
+
+func (this *Formatter) Format(record *iface.Record) []byte {
+	this.lock.Lock()
+
+	buf := this.buf[:0]
+	// 64-bit integers are strings in OTLP/JSON
+	buf = append(buf, `{"timeUnixNano":"`...)
+	buf = strconv.AppendInt(buf, record.Time.UnixNano(), 10)
+	buf = append(buf, `","severityNumber":`...)
+	buf = strconv.AppendInt(buf, int64(SeverityNumber(record.Level)), 10)
+	buf = append(buf, `,"severityText":`...)
+	buf = util.AppendJSONString(buf, SeverityText(record.Level))
+	buf = append(buf, `,"body":{"stringValue":`...)
+	buf = util.AppendJSONString(buf, record.Msg)
+	buf = append(buf, `},"attributes":[`...)
+
+	file := util.LastSegments(record.File, this.fileSegs, '/')
+	// avoid to omit the root path '/'
+	if len(file)+1 == len(record.File) {
+		file = record.File
+	}
+	buf = appendAttribute(buf, "", "code.filepath", iface.StringValue(file))
+	buf = appendAttribute(buf, ",", "code.lineno", iface.Int64Value(int64(record.Line)))
+	pkg := util.LastSegments(record.Pkg, this.pkgSegs, '/')
+	buf = appendAttribute(buf, ",", "code.namespace", iface.StringValue(pkg))
+	fn := util.LastSegments(record.Func, this.funcSegs, '.')
+	buf = appendAttribute(buf, ",", "code.function", iface.StringValue(fn))
+	if record.Stack != "" {
+		buf = appendAttribute(buf, ",", "code.stacktrace", iface.StringValue(record.Stack))
+	}
+	if record.Prefix != "" {
+		buf = appendAttribute(buf, ",", "gxlog.prefix", iface.StringValue(record.Prefix))
+	}
+	if record.Mark {
+		buf = appendAttribute(buf, ",", "gxlog.mark", iface.BoolValue(true))
+	}
+	for _, context := range record.Contexts {
+		buf = appendAttribute(buf, ",", context.Key, context.Value)
+	}
+
+	buf = append(buf, "]}\n"...)
+	this.buf = buf
+
+	this.lock.Unlock()
+
+	return buf
+}
+
+func (this *Formatter) FileSegs() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.fileSegs
+}
+
+func (this *Formatter) SetFileSegs(segs int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.fileSegs = segs
+}
+
+func (this *Formatter) PkgSegs() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.pkgSegs
+}
+
+func (this *Formatter) SetPkgSegs(segs int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.pkgSegs = segs
+}
+
+func (this *Formatter) FuncSegs() int {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.funcSegs
+}
+
+func (this *Formatter) SetFuncSegs(segs int) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.funcSegs = segs
+}
+
+// appendAttribute appends a KeyValue.
+func appendAttribute(buf []byte, sep, key string, value iface.Value) []byte {
+	buf = append(buf, sep...)
+	buf = append(buf, `{"key":`...)
+	buf = util.AppendJSONString(buf, key)
+	buf = append(buf, `,"value":`...)
+	buf = appendAnyValue(buf, value)
+	return append(buf, "}"...)
+}
+
+// appendAnyValue appends an AnyValue. Durations are integers of nanoseconds,
+// and times are strings of RFC 3339.
+func appendAnyValue(buf []byte, value iface.Value) []byte {
+	switch value.Kind() {
+	case iface.KindString:
+		buf = append(buf, `{"stringValue":`...)
+		buf = util.AppendJSONString(buf, value.Str())
+	case iface.KindInt64:
+		buf = append(buf, `{"intValue":"`...)
+		buf = strconv.AppendInt(buf, value.Int64(), 10)
+		buf = append(buf, `"`...)
+	case iface.KindUint64:
+		if value.Uint64() > math.MaxInt64 {
+			buf = append(buf, `{"stringValue":"`...)
+		} else {
+			buf = append(buf, `{"intValue":"`...)
+		}
+		buf = strconv.AppendUint(buf, value.Uint64(), 10)
+		buf = append(buf, `"`...)
+	case iface.KindFloat64:
+		buf = append(buf, `{"doubleValue":`...)
+		f := value.Float64()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			// "NaN", "Infinity" and "-Infinity" of the JSON mapping of proto3
+			name := "NaN"
+			if math.IsInf(f, 1) {
+				name = "Infinity"
+			} else if math.IsInf(f, -1) {
+				name = "-Infinity"
+			}
+			buf = util.AppendJSONString(buf, name)
+		} else {
+			buf = strconv.AppendFloat(buf, f, 'g', -1, 64)
+		}
+	case iface.KindBool:
+		buf = append(buf, `{"boolValue":`...)
+		buf = strconv.AppendBool(buf, value.Bool())
+	case iface.KindDuration:
+		buf = append(buf, `{"intValue":"`...)
+		buf = strconv.AppendInt(buf, int64(value.Duration()), 10)
+		buf = append(buf, `"`...)
+	case iface.KindTime:
+		buf = append(buf, `{"stringValue":"`...)
+		buf = value.Time().AppendFormat(buf, time.RFC3339Nano)
+		buf = append(buf, `"`...)
+	case iface.KindObject:
+		buf = append(buf, `{"kvlistValue":{"values":[`...)
+		for i, context := range value.Object() {
+			sep := ","
+			if i == 0 {
+				sep = ""
+			}
+			buf = appendAttribute(buf, sep, context.Key, context.Value)
+		}
+		buf = append(buf, "]}"...)
+	default:
+		buf = append(buf, `{"stringValue":`...)
+		buf = util.AppendJSONString(buf, value.String())
+	}
+	return append(buf, "}"...)
+}
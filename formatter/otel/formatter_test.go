@@ -0,0 +1,72 @@
+package otel_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gratonos/gxlog/formatter/otel"
+	"github.com/gratonos/gxlog/iface"
+)
+
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		level  iface.Level
+		number int
+		text   string
+	}{
+		{iface.Trace, 1, "TRACE"},
+		{iface.Debug, 5, "DEBUG"},
+		{iface.Info, 9, "INFO"},
+		{iface.Warn, 13, "WARN"},
+		{iface.Error, 17, "ERROR"},
+		{iface.Fatal, 21, "FATAL"},
+	}
+	for _, test := range tests {
+		if number := otel.SeverityNumber(test.level); number != test.number {
+			t.Errorf("TestSeverity: %v: want %d, got %d", test.level, test.number, number)
+		}
+		if text := otel.SeverityText(test.level); text != test.text {
+			t.Errorf("TestSeverity: %v: want %q, got %q", test.level, test.text, text)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	formatter := otel.New(otel.Config{PkgSegs: 1})
+	record := &iface.Record{
+		Time:   time.Unix(1, 23),
+		Level:  iface.Error,
+		File:   "/src/app/main.go",
+		Line:   42,
+		Pkg:    "github.com/org/app",
+		Func:   "run",
+		Msg:    "failed",
+		Stack:  "main.main()\n",
+		Prefix: "** ",
+		Mark:   true,
+		Contexts: []iface.Context{
+			iface.Uint64("big", math.MaxUint64),
+			iface.Float64("nan", math.NaN()),
+			iface.Duration("elapsed", time.Second),
+			iface.Object("user", iface.String("name", "alice")),
+		},
+	}
+	expect := `{"timeUnixNano":"1000000023","severityNumber":17,"severityText":"ERROR",` +
+		`"body":{"stringValue":"failed"},"attributes":[` +
+		`{"key":"code.filepath","value":{"stringValue":"/src/app/main.go"}},` +
+		`{"key":"code.lineno","value":{"intValue":"42"}},` +
+		`{"key":"code.namespace","value":{"stringValue":"app"}},` +
+		`{"key":"code.function","value":{"stringValue":"run"}},` +
+		`{"key":"code.stacktrace","value":{"stringValue":"main.main()\n"}},` +
+		`{"key":"gxlog.prefix","value":{"stringValue":"** "}},` +
+		`{"key":"gxlog.mark","value":{"boolValue":true}},` +
+		`{"key":"big","value":{"stringValue":"18446744073709551615"}},` +
+		`{"key":"nan","value":{"doubleValue":"NaN"}},` +
+		`{"key":"elapsed","value":{"intValue":"1000000000"}},` +
+		`{"key":"user","value":{"kvlistValue":{"values":[{"key":"name","value":{"stringValue":"alice"}}]}}}` +
+		"]}\n"
+	if output := string(formatter.Format(record)); output != expect {
+		t.Errorf("TestFormat:\noutput: %s\nexpect: %s", output, expect)
+	}
+}
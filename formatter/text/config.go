@@ -50,6 +50,9 @@ type Config struct {
 	//    prefix  |                          |           %s |
 	//    context | <pair|list>              | "pair"    %s | "pair", "list"
 	//    msg     |                          |           %s |
+	//    stack   |                          |           %s |
+	// The stack is empty unless it is captured by the Logger, and it ends with
+	// a newline if it is NOT empty, e.g. "{{msg}}\n{{stack}}".
 	Header    string
 	Coloring  bool
 	ColorMap  map[iface.Level]Color
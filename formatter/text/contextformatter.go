@@ -2,6 +2,7 @@ package text
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/gratonos/gxlog/iface"
@@ -47,7 +48,7 @@ func formatPair(buf []byte, contexts []iface.Context) []byte {
 		buf = append(buf, left...)
 		buf = append(buf, ctx.Key...)
 		buf = append(buf, ": "...)
-		buf = append(buf, ctx.Value...)
+		buf = appendValue(buf, ctx.Value)
 		buf = append(buf, ')')
 		left = " ("
 	}
@@ -60,8 +61,40 @@ func formatList(buf []byte, contexts []iface.Context) []byte {
 		buf = append(buf, begin...)
 		buf = append(buf, ctx.Key...)
 		buf = append(buf, ": "...)
-		buf = append(buf, ctx.Value...)
+		buf = appendValue(buf, ctx.Value)
 		begin = ", "
 	}
 	return buf
 }
+
+// appendValue quotes strings that are empty or contain spaces, quotes or
+// control characters, and formats objects as {key: value, key: value}.
+func appendValue(buf []byte, value iface.Value) []byte {
+	switch value.Kind() {
+	case iface.KindString, iface.KindError, iface.KindAny:
+		str := value.String()
+		if needQuoting(str) {
+			return strconv.AppendQuote(buf, str)
+		}
+		return append(buf, str...)
+	case iface.KindObject:
+		buf = append(buf, '{')
+		buf = formatList(buf, value.Object())
+		return append(buf, '}')
+	default:
+		return value.Append(buf)
+	}
+}
+
+func needQuoting(str string) bool {
+	if str == "" {
+		return true
+	}
+	for i := 0; i < len(str); i++ {
+		b := str[i]
+		if b <= ' ' || b == '"' || b == '\\' || b == '\u007f' {
+			return true
+		}
+	}
+	return false
+}
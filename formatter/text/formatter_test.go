@@ -1,6 +1,7 @@
 package text_test
 
 import (
+	"errors"
 	"fmt"
 	"path"
 	"path/filepath"
@@ -88,6 +89,14 @@ func TestBizarreHeader(t *testing.T) {
 	testFormat(t, formatter, tmplRecord(), expect)
 }
 
+func TestStack(t *testing.T) {
+	formatter := text.New(text.Config{Header: "{{msg}}\n{{stack}}"})
+	record := tmplRecord()
+	record.Stack = "main.main()\n\t/home/test/main.go:10\n"
+	expect := tmplMsg + "\n" + record.Stack
+	testFormat(t, formatter, record, expect)
+}
+
 func TestColor(t *testing.T) {
 	formatter := text.New(text.Config{Header: "{{msg}}", Coloring: true})
 	expect := fmt.Sprintf("\033[%dm%s\033[0m", text.Magenta, tmplMsg)
@@ -108,6 +117,24 @@ func TestColor(t *testing.T) {
 	testFormat(t, formatter, record, expect)
 }
 
+func TestTypedContexts(t *testing.T) {
+	formatter := text.New(text.Config{Header: "{{context}}"})
+	record := tmplRecord()
+	record.Contexts = []iface.Context{
+		iface.Int("int", -1),
+		iface.Float64("float", 1.5),
+		iface.Bool("bool", true),
+		iface.Duration("duration", time.Second),
+		iface.String("empty", ""),
+		iface.String("text", "a \"b\""),
+		iface.Err("error", errors.New("not found")),
+		iface.Object("object", iface.String("k", "v"), iface.Int("n", 1)),
+	}
+	expect := `(int: -1) (float: 1.5) (bool: true) (duration: 1s) (empty: "") ` +
+		`(text: "a \"b\"") (error: "not found") (object: {k: v, n: 1})`
+	testFormat(t, formatter, record, expect)
+}
+
 func testFormat(t *testing.T, formatter iface.Formatter, record *iface.Record, expect string) {
 	output := string(formatter.Format(record))
 	if output != expect {
@@ -126,8 +153,8 @@ func tmplRecord() *iface.Record {
 		Msg:    tmplMsg,
 		Prefix: tmplPrefix,
 		Contexts: []iface.Context{
-			{Key: "k1", Value: "v1"},
-			{Key: "k2", Value: "v2"},
+			iface.String("k1", "v1"),
+			iface.String("k2", "v2"),
 		},
 		Mark: true,
 	}
@@ -16,6 +16,7 @@ var newFormatterFuncMap = map[string]func(property, fmtstr string) elementFormat
 	"pkg":     newPkgFormatter,
 	"func":    newFuncFormatter,
 	"msg":     newMsgFormatter,
+	"stack":   newStackFormatter,
 	"prefix":  newPrefixFormatter,
 	"context": newContextFormatter,
 }
@@ -0,0 +1,26 @@
+package text
+
+import (
+	"fmt"
+
+	"github.com/gratonos/gxlog/iface"
+)
+
+type stackFormatter struct {
+	fmtstr string
+}
+
+func newStackFormatter(_, fmtstr string) elementFormatter {
+	if fmtstr == "" {
+		fmtstr = "%s"
+	}
+	return &stackFormatter{fmtstr: fmtstr}
+}
+
+func (this *stackFormatter) FormatElement(buf []byte, record *iface.Record) []byte {
+	if this.fmtstr == "%s" {
+		return append(buf, record.Stack...)
+	} else {
+		return append(buf, fmt.Sprintf(this.fmtstr, record.Stack)...)
+	}
+}
@@ -2,6 +2,8 @@
 package iface
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -19,9 +21,37 @@ const (
 
 const LogLevelCount = Off - Trace
 
+var levelNames = []string{
+	Trace: "trace",
+	Debug: "debug",
+	Info:  "info",
+	Warn:  "warn",
+	Error: "error",
+	Fatal: "fatal",
+	Off:   "off",
+}
+
+func (self Level) String() string {
+	if self < Trace || self > Off {
+		return fmt.Sprintf("Level(%d)", int32(self))
+	}
+	return levelNames[self]
+}
+
+// ParseLevel parses the name of a level case-insensitively, e.g. "info", "WARN".
+func ParseLevel(name string) (Level, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for level, levelName := range levelNames {
+		if name == levelName {
+			return Level(level), nil
+		}
+	}
+	return 0, fmt.Errorf("iface.ParseLevel: unknown level %q", name)
+}
+
 type Context struct {
 	Key   string
-	Value string
+	Value Value
 }
 
 type Record struct {
@@ -32,6 +62,7 @@ type Record struct {
 	Pkg   string
 	Func  string
 	Msg   string
+	Stack string // empty if the stack is NOT captured
 
 	Prefix   string
 	Contexts []Context
@@ -45,3 +76,13 @@ type Formatter interface {
 type Writer interface {
 	Write(bs []byte, record *Record) error
 }
+
+// A Flusher is a Writer that buffers logs and is able to flush them.
+type Flusher interface {
+	Flush() error
+}
+
+// A Closer is a Writer that holds resources and needs to be closed.
+type Closer interface {
+	Close() error
+}
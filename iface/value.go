@@ -0,0 +1,326 @@
+package iface
+
+import (
+	ejson "encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+type Kind uint8
+
+const (
+	KindString Kind = iota
+	KindInt64
+	KindUint64
+	KindFloat64
+	KindBool
+	KindDuration
+	KindTime
+	KindError
+	KindObject
+	KindAny
+)
+
+// A Value is a typed value of a Context. The zero Value is an empty string.
+// Values of all kinds except KindObject and KindAny are constructed without
+// allocation.
+type Value struct {
+	kind Kind
+	num  uint64
+	str  string
+	any  interface{}
+}
+
+func StringValue(value string) Value {
+	return Value{kind: KindString, str: value}
+}
+
+func Int64Value(value int64) Value {
+	return Value{kind: KindInt64, num: uint64(value)}
+}
+
+func Uint64Value(value uint64) Value {
+	return Value{kind: KindUint64, num: value}
+}
+
+func Float64Value(value float64) Value {
+	return Value{kind: KindFloat64, num: math.Float64bits(value)}
+}
+
+func BoolValue(value bool) Value {
+	var num uint64
+	if value {
+		num = 1
+	}
+	return Value{kind: KindBool, num: num}
+}
+
+func DurationValue(value time.Duration) Value {
+	return Value{kind: KindDuration, num: uint64(value)}
+}
+
+func TimeValue(value time.Time) Value {
+	// UnixNano is only valid between the year 1678 and 2262
+	if year := value.Year(); year < 1678 || year > 2261 {
+		return Value{kind: KindTime, any: value}
+	}
+	return Value{kind: KindTime, num: uint64(value.UnixNano()), any: value.Location()}
+}
+
+func ErrorValue(err error) Value {
+	return Value{kind: KindError, any: err}
+}
+
+func ObjectValue(contexts ...Context) Value {
+	return Value{kind: KindObject, any: contexts}
+}
+
+// AnyValue returns a Value of the most suitable kind of the value.
+func AnyValue(value interface{}) Value {
+	switch v := value.(type) {
+	case string:
+		return StringValue(v)
+	case int:
+		return Int64Value(int64(v))
+	case int8:
+		return Int64Value(int64(v))
+	case int16:
+		return Int64Value(int64(v))
+	case int32:
+		return Int64Value(int64(v))
+	case int64:
+		return Int64Value(v)
+	case uint:
+		return Uint64Value(uint64(v))
+	case uint8:
+		return Uint64Value(uint64(v))
+	case uint16:
+		return Uint64Value(uint64(v))
+	case uint32:
+		return Uint64Value(uint64(v))
+	case uint64:
+		return Uint64Value(v)
+	case uintptr:
+		return Uint64Value(uint64(v))
+	case float32:
+		return Float64Value(float64(v))
+	case float64:
+		return Float64Value(v)
+	case bool:
+		return BoolValue(v)
+	case time.Duration:
+		return DurationValue(v)
+	case time.Time:
+		return TimeValue(v)
+	case error:
+		return ErrorValue(v)
+	case []Context:
+		return ObjectValue(v...)
+	case Value:
+		return v
+	default:
+		return Value{kind: KindAny, any: v}
+	}
+}
+
+func (self Value) Kind() Kind {
+	return self.kind
+}
+
+// Str returns the string of a Value of KindString. Use String to get the text
+// representation of a Value of any kind.
+func (self Value) Str() string {
+	return self.str
+}
+
+func (self Value) Int64() int64 {
+	return int64(self.num)
+}
+
+func (self Value) Uint64() uint64 {
+	return self.num
+}
+
+func (self Value) Float64() float64 {
+	return math.Float64frombits(self.num)
+}
+
+func (self Value) Bool() bool {
+	return self.num != 0
+}
+
+func (self Value) Duration() time.Duration {
+	return time.Duration(self.num)
+}
+
+func (self Value) Time() time.Time {
+	if location, ok := self.any.(*time.Location); ok {
+		return time.Unix(0, int64(self.num)).In(location)
+	}
+	t, _ := self.any.(time.Time)
+	return t
+}
+
+func (self Value) Err() error {
+	err, _ := self.any.(error)
+	return err
+}
+
+func (self Value) Object() []Context {
+	contexts, _ := self.any.([]Context)
+	return contexts
+}
+
+// Any returns the value as it is passed to the constructor.
+func (self Value) Any() interface{} {
+	switch self.kind {
+	case KindString:
+		return self.str
+	case KindInt64:
+		return self.Int64()
+	case KindUint64:
+		return self.num
+	case KindFloat64:
+		return self.Float64()
+	case KindBool:
+		return self.Bool()
+	case KindDuration:
+		return self.Duration()
+	case KindTime:
+		return self.Time()
+	default:
+		return self.any
+	}
+}
+
+// Append appends the text representation of the Value to buf.
+// Objects are represented as {key: value, key: value}.
+func (self Value) Append(buf []byte) []byte {
+	switch self.kind {
+	case KindString:
+		return append(buf, self.str...)
+	case KindInt64:
+		return strconv.AppendInt(buf, self.Int64(), 10)
+	case KindUint64:
+		return strconv.AppendUint(buf, self.num, 10)
+	case KindFloat64:
+		return strconv.AppendFloat(buf, self.Float64(), 'g', -1, 64)
+	case KindBool:
+		return strconv.AppendBool(buf, self.Bool())
+	case KindDuration:
+		return append(buf, self.Duration().String()...)
+	case KindTime:
+		return self.Time().AppendFormat(buf, time.RFC3339Nano)
+	case KindObject:
+		buf = append(buf, '{')
+		for i, context := range self.Object() {
+			if i > 0 {
+				buf = append(buf, ", "...)
+			}
+			buf = append(buf, context.Key...)
+			buf = append(buf, ": "...)
+			buf = context.Value.Append(buf)
+		}
+		return append(buf, '}')
+	default:
+		return append(buf, fmt.Sprint(self.any)...)
+	}
+}
+
+func (self Value) String() string {
+	if self.kind == KindString {
+		return self.str
+	}
+	return string(self.Append(nil))
+}
+
+// MarshalJSON renders numbers and booleans natively, durations as integers
+// of nanoseconds and objects as JSON objects.
+func (self Value) MarshalJSON() ([]byte, error) {
+	switch self.kind {
+	case KindString:
+		return ejson.Marshal(self.str)
+	case KindInt64, KindUint64, KindBool:
+		return self.Append(nil), nil
+	case KindDuration:
+		return strconv.AppendInt(nil, int64(self.Duration()), 10), nil
+	case KindFloat64:
+		f := self.Float64()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return ejson.Marshal(self.String())
+		}
+		return self.Append(nil), nil
+	case KindTime:
+		return self.Time().MarshalJSON()
+	case KindError:
+		return ejson.Marshal(self.String())
+	case KindObject:
+		buf := []byte{'{'}
+		for i, context := range self.Object() {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			key, _ := ejson.Marshal(context.Key)
+			buf = append(buf, key...)
+			buf = append(buf, ':')
+			value, err := context.Value.MarshalJSON()
+			if err != nil {
+				return nil, err
+			}
+			buf = append(buf, value...)
+		}
+		return append(buf, '}'), nil
+	default:
+		bs, err := ejson.Marshal(self.any)
+		if err != nil {
+			return ejson.Marshal(self.String())
+		}
+		return bs, nil
+	}
+}
+
+func String(key, value string) Context {
+	return Context{Key: key, Value: StringValue(value)}
+}
+
+func Int(key string, value int) Context {
+	return Context{Key: key, Value: Int64Value(int64(value))}
+}
+
+func Int64(key string, value int64) Context {
+	return Context{Key: key, Value: Int64Value(value)}
+}
+
+func Uint64(key string, value uint64) Context {
+	return Context{Key: key, Value: Uint64Value(value)}
+}
+
+func Float64(key string, value float64) Context {
+	return Context{Key: key, Value: Float64Value(value)}
+}
+
+func Bool(key string, value bool) Context {
+	return Context{Key: key, Value: BoolValue(value)}
+}
+
+func Duration(key string, value time.Duration) Context {
+	return Context{Key: key, Value: DurationValue(value)}
+}
+
+func Time(key string, value time.Time) Context {
+	return Context{Key: key, Value: TimeValue(value)}
+}
+
+func Err(key string, err error) Context {
+	return Context{Key: key, Value: ErrorValue(err)}
+}
+
+func Object(key string, contexts ...Context) Context {
+	return Context{Key: key, Value: ObjectValue(contexts...)}
+}
+
+func Any(key string, value interface{}) Context {
+	return Context{Key: key, Value: AnyValue(value)}
+}
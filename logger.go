@@ -2,6 +2,7 @@
 package gxlog
 
 import (
+	"context"
 	"os"
 
 	"github.com/gratonos/gxlog/formatter/text"
@@ -31,3 +32,12 @@ func Logger() *logger.Logger {
 func Formatter() *text.Formatter {
 	return defaultFormatter
 }
+
+// FromContext returns the Logger carried by ctx, or the default Logger if
+// there is none.
+func FromContext(ctx context.Context) *logger.Logger {
+	if log := logger.FromContext(ctx); log != nil {
+		return log
+	}
+	return defaultLogger
+}
@@ -44,6 +44,13 @@ func (self Logger) WithStatics(kvs ...interface{}) *Logger {
 	return &self
 }
 
+// WithContexts is like WithStatics, but takes typed contexts directly.
+func (self Logger) WithContexts(contexts ...iface.Context) *Logger {
+	statics := cloneStatics(self.additional.Statics, len(contexts))
+	self.additional.Statics = append(statics, contexts...)
+	return &self
+}
+
 func (self Logger) WithDynamics(kvs ...interface{}) *Logger {
 	dynamics := cloneDynamics(self.additional.Dynamics, keyValuePairs(len(kvs)))
 	self.additional.Dynamics = appendDynamics(dynamics, kvs)
@@ -71,10 +78,7 @@ func cloneStatics(statics []iface.Context, delta int) []iface.Context {
 func appendStatics(statics []iface.Context, kvs []interface{}) []iface.Context {
 	// len(kvs) is even, checked by func keyValuePairs
 	for len(kvs) > 0 {
-		statics = append(statics, iface.Context{
-			Key:   fmt.Sprint(kvs[0]),
-			Value: fmt.Sprint(kvs[1]),
-		})
+		statics = append(statics, iface.Any(fmt.Sprint(kvs[0]), kvs[1]))
 		kvs = kvs[2:]
 	}
 	return statics
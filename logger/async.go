@@ -0,0 +1,174 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gratonos/gxlog/iface"
+)
+
+// An OverflowPolicy specifies what to do when the queue of an async Logger is full.
+type OverflowPolicy int
+
+const (
+	// Block makes the caller wait until there is room in the queue.
+	Block OverflowPolicy = iota
+	// DropNewest drops the record being emitted.
+	DropNewest
+	// DropOldest drops the oldest record in the queue to make room.
+	DropOldest
+	// DropBelowLevel drops the record being emitted if its level is lower than
+	// Config.DropLevel, otherwise the caller waits as Block does.
+	DropBelowLevel
+)
+
+type queueEntry struct {
+	record *iface.Record
+	filter Filter
+}
+
+type asyncQueue struct {
+	entries   []queueEntry // ring buffer
+	head      int
+	size      int
+	policy    OverflowPolicy
+	dropLevel iface.Level
+	busy      bool
+	closed    bool
+	dropped   uint64
+
+	lock     sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	idle     *sync.Cond
+	done     chan struct{}
+}
+
+func newAsyncQueue(config *Config) *asyncQueue {
+	queue := &asyncQueue{
+		entries:   make([]queueEntry, config.QueueSize),
+		policy:    config.Overflow,
+		dropLevel: config.DropLevel,
+		done:      make(chan struct{}),
+	}
+	queue.notEmpty = sync.NewCond(&queue.lock)
+	queue.notFull = sync.NewCond(&queue.lock)
+	queue.idle = sync.NewCond(&queue.lock)
+	return queue
+}
+
+// Push returns false if the queue has been closed and the record is NOT queued.
+func (this *asyncQueue) Push(record *iface.Record, filter Filter) bool {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	for !this.closed && this.size == len(this.entries) {
+		switch this.policy {
+		case DropNewest:
+			this.dropped++
+			return true
+		case DropOldest:
+			this.entries[this.head] = queueEntry{}
+			this.head = (this.head + 1) % len(this.entries)
+			this.size--
+			this.dropped++
+		case DropBelowLevel:
+			if record.Level < this.dropLevel {
+				this.dropped++
+				return true
+			}
+			this.notFull.Wait()
+		default:
+			this.notFull.Wait()
+		}
+	}
+	if this.closed {
+		return false
+	}
+
+	// the time is taken under the lock to keep records in order
+	record.Time = time.Now()
+	tail := (this.head + this.size) % len(this.entries)
+	this.entries[tail] = queueEntry{record: record, filter: filter}
+	this.size++
+	this.notEmpty.Signal()
+	return true
+}
+
+// Pop returns false if the queue has been closed and drained.
+func (this *asyncQueue) Pop() (queueEntry, bool) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	for this.size == 0 && !this.closed {
+		this.notEmpty.Wait()
+	}
+	if this.size == 0 {
+		return queueEntry{}, false
+	}
+
+	entry := this.entries[this.head]
+	this.entries[this.head] = queueEntry{}
+	this.head = (this.head + 1) % len(this.entries)
+	this.size--
+	this.busy = true
+	this.notFull.Signal()
+	return entry, true
+}
+
+// Done MUST be called after an entry returned by Pop has been processed.
+func (this *asyncQueue) Done() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.busy = false
+	if this.size == 0 {
+		this.idle.Broadcast()
+	}
+}
+
+func (this *asyncQueue) Wait() {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	for this.size > 0 || this.busy {
+		this.idle.Wait()
+	}
+}
+
+func (this *asyncQueue) Close() {
+	this.lock.Lock()
+	this.closed = true
+	this.notEmpty.Broadcast()
+	this.notFull.Broadcast()
+	this.lock.Unlock()
+
+	<-this.done
+}
+
+func (this *asyncQueue) Dropped() uint64 {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return this.dropped
+}
+
+func (this *Logger) serve() {
+	for {
+		entry, ok := this.queue.Pop()
+		if !ok {
+			break
+		}
+
+		this.lock.Lock()
+		record := entry.record
+		if entry.filter(record) && this.config.Filter(record) {
+			this.formatAndWrite(record.Level, record)
+		}
+		this.lock.Unlock()
+
+		this.queue.Done()
+	}
+
+	close(this.queue.done)
+}
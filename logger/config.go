@@ -1,14 +1,51 @@
 package logger
 
 import (
+	"os"
+
 	"github.com/gratonos/gxlog/iface"
 )
 
+const defaultQueueSize = 1024
+
 type Config struct {
 	Level  iface.Level
 	Filter Filter
+	// Extractors extract contexts from a context.Context, see InfoCtx.
+	Extractors []Extractor
+
+	// If Async is true, records are put into a bounded queue and formatted and
+	// written by a background goroutine in the order they are emitted.
+	// Call Flush or Close of the Logger to wait for the queued records.
+	Async bool
+	// QueueSize is the capacity of the queue in async mode. Defaults to 1024.
+	QueueSize int
+	// Overflow specifies what to do when the queue is full in async mode.
+	Overflow OverflowPolicy
+	// DropLevel is only used with the overflow policy DropBelowLevel.
+	DropLevel iface.Level
+
+	// If CaptureStack is true, the call stack is captured for a record whose
+	// level is NOT lower than StackLevel, see iface.Record.Stack.
+	CaptureStack bool
+	StackLevel   iface.Level
+
+	// Exit is called by Fatal, Fatalf, Fatalw and FatalCtx after the Logger is
+	// flushed.
+	// Defaults to os.Exit. It is mainly used for testing.
+	Exit func(code int)
 }
 
 func (this *Config) SetDefaults() {
 	this.Filter = fillFilter(this.Filter)
+
+	if this.QueueSize <= 0 {
+		this.QueueSize = defaultQueueSize
+	}
+	if !this.CaptureStack {
+		this.StackLevel = iface.Off
+	}
+	if this.Exit == nil {
+		this.Exit = os.Exit
+	}
 }
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gratonos/gxlog/iface"
+)
+
+// An Extractor appends the contexts extracted from ctx, e.g. a request ID,
+// to contexts and returns the result. Extractors are called by the ctx-aware
+// methods of a Logger, e.g. InfoCtx.
+// Do NOT call any method of the Logger within an Extractor, or it may deadlock.
+type Extractor func(ctx context.Context, contexts []iface.Context) []iface.Context
+
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	contextsKey
+)
+
+// NewContext returns a copy of ctx that carries the logger.
+func NewContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// FromContext returns the Logger carried by ctx, or nil if there is none.
+func FromContext(ctx context.Context) *Logger {
+	logger, _ := ctx.Value(loggerKey).(*Logger)
+	return logger
+}
+
+// AppendContexts returns a copy of ctx that carries the contexts in addition
+// to the ones already carried by ctx. The contexts carried by ctx are added
+// to the records emitted by the ctx-aware methods of a Logger.
+func AppendContexts(ctx context.Context, contexts ...iface.Context) context.Context {
+	prev := ContextsFrom(ctx)
+	all := make([]iface.Context, 0, len(prev)+len(contexts))
+	all = append(all, prev...)
+	all = append(all, contexts...)
+	return context.WithValue(ctx, contextsKey, all)
+}
+
+// ContextsFrom returns the contexts carried by ctx. The result MUST NOT be modified.
+func ContextsFrom(ctx context.Context) []iface.Context {
+	contexts, _ := ctx.Value(contextsKey).([]iface.Context)
+	return contexts
+}
+
+func (this *Logger) Extractors() []Extractor {
+	return this.extractors.Load().([]Extractor)
+}
+
+func (this *Logger) SetExtractors(extractors ...Extractor) {
+	this.extractors.Store(extractors)
+}
+
+func (this *Logger) TraceCtx(ctx context.Context, args ...interface{}) {
+	this.LogCtx(1, ctx, iface.Trace, args...)
+}
+
+func (this *Logger) DebugCtx(ctx context.Context, args ...interface{}) {
+	this.LogCtx(1, ctx, iface.Debug, args...)
+}
+
+func (this *Logger) InfoCtx(ctx context.Context, args ...interface{}) {
+	this.LogCtx(1, ctx, iface.Info, args...)
+}
+
+func (this *Logger) WarnCtx(ctx context.Context, args ...interface{}) {
+	this.LogCtx(1, ctx, iface.Warn, args...)
+}
+
+func (this *Logger) ErrorCtx(ctx context.Context, args ...interface{}) {
+	this.LogCtx(1, ctx, iface.Error, args...)
+}
+
+func (this *Logger) FatalCtx(ctx context.Context, args ...interface{}) {
+	this.LogCtx(1, ctx, iface.Fatal, args...)
+	_ = this.Flush()
+	this.config.Exit(1)
+}
+
+// LogCtx is like Log, but the contexts carried by ctx and the ones extracted
+// by the extractors of the Logger are added to the record.
+func (this *Logger) LogCtx(callDepth int, ctx context.Context, level iface.Level,
+	args ...interface{}) {
+	if pos, ok := this.locate(callDepth, level); ok {
+		this.emit(level, &pos, fmt.Sprint(args...), this.extract(ctx, nil))
+	}
+}
+
+func (this *Logger) extract(ctx context.Context, contexts []iface.Context) []iface.Context {
+	if ctx == nil {
+		return contexts
+	}
+	contexts = append(contexts, ContextsFrom(ctx)...)
+	for _, extractor := range this.Extractors() {
+		contexts = extractor(ctx, contexts)
+	}
+	return contexts
+}
@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gratonos/gxlog/iface"
+)
+
+func wrapError(cause error, msg string) error {
+	if cause == nil {
+		return errors.New(msg)
+	}
+	return fmt.Errorf("%s: %w", msg, cause)
+}
+
+// errorChain returns a context of an object keyed by the indexes of err and
+// the errors unwrapped from it, e.g. (chain: {0: "b: a", 1: a}).
+func errorChain(err error) []iface.Context {
+	if err == nil {
+		return nil
+	}
+	var chain []iface.Context
+	for i := 0; err != nil; i++ {
+		chain = append(chain, iface.Err(strconv.Itoa(i), err))
+		err = errors.Unwrap(err)
+	}
+	return []iface.Context{iface.Object("chain", chain...)}
+}
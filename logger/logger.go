@@ -2,9 +2,9 @@
 package logger
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -23,26 +23,39 @@ const (
 // a Formatter and a Writer. A Logger has its own level and filter while each
 // Slot has its independent level and filter. Logger calls the Formatter and
 // Writer of each Slot in the order from Slot0 to Slot7 when a log is emitted.
+// In async mode, the Formatters and Writers are called by a background goroutine.
 type Logger struct {
 	additional additional // copy on write, concurrency safe
 
 	config      *Config
 	slots       []Slot
-	equivalents [][]int // indexes of equivalent formatters, used to avoid duplicated formatting
+	equivalents [][]int       // indexes of equivalent formatters, used to avoid duplicated formatting
+	queue       *asyncQueue   // nil if NOT in async mode
+	vmodule     *atomic.Value // *vmodule, nil if there is no level override
+	extractors  *atomic.Value // []Extractor, loaded without the lock
 	lock        *sync.Mutex
 }
 
 func New(config Config) *Logger {
 	config.SetDefaults()
-	return &Logger{
+	logger := &Logger{
 		additional: additional{
 			Filter: nullFilter,
 		},
 		config:      &config,
 		slots:       initSlots(),
 		equivalents: make([][]int, MaxSlot),
+		vmodule:     new(atomic.Value),
+		extractors:  new(atomic.Value),
 		lock:        new(sync.Mutex),
 	}
+	logger.vmodule.Store((*vmodule)(nil))
+	logger.extractors.Store(config.Extractors)
+	if config.Async {
+		logger.queue = newAsyncQueue(&config)
+		go logger.serve()
+	}
+	return logger
 }
 
 func (this *Logger) Level() iface.Level {
@@ -53,6 +66,18 @@ func (this *Logger) SetLevel(level iface.Level) {
 	atomic.StoreInt32((*int32)(&this.config.Level), int32(level))
 }
 
+// StackLevel returns the lowest level of records whose stacks are captured.
+// It returns iface.Off if stacks are NOT captured.
+func (this *Logger) StackLevel() iface.Level {
+	return iface.Level(atomic.LoadInt32((*int32)(&this.config.StackLevel)))
+}
+
+// SetStackLevel sets the lowest level of records whose stacks are captured.
+// Use iface.Off to disable capturing.
+func (this *Logger) SetStackLevel(level iface.Level) {
+	atomic.StoreInt32((*int32)(&this.config.StackLevel), int32(level))
+}
+
 func (this *Logger) Filter() Filter {
 	this.lock.Lock()
 	defer this.lock.Unlock()
@@ -67,6 +92,72 @@ func (this *Logger) SetFilter(filter Filter) {
 	this.config.Filter = fillFilter(filter)
 }
 
+// Flush waits until all the queued records have been written in async mode,
+// and then flushes the Writer of each Slot that implements iface.Flusher.
+// A Writer shared by multiple slots is flushed only once.
+func (this *Logger) Flush() error {
+	if this.queue != nil {
+		this.queue.Wait()
+	}
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	var firstErr error
+	for _, writer := range this.uniqueWriters() {
+		if flusher, ok := writer.(iface.Flusher); ok {
+			if err := flusher.Flush(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if firstErr != nil {
+		return fmt.Errorf("logger.Flush: %v", firstErr)
+	}
+	return nil
+}
+
+// Close flushes the queued records and stops the background goroutine in
+// async mode, and then closes the Writer of each Slot that implements
+// iface.Closer. A Writer that is NOT a closer but a flusher is flushed instead.
+// A Writer shared by multiple slots is closed only once. The Writers are NOT
+// removed from the slots, so the Logger should NOT be used after Close.
+func (this *Logger) Close() error {
+	if this.queue != nil {
+		this.queue.Close()
+	}
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	var firstErr error
+	for _, writer := range this.uniqueWriters() {
+		var err error
+		switch w := writer.(type) {
+		case iface.Closer:
+			err = w.Close()
+		case iface.Flusher:
+			err = w.Flush()
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return fmt.Errorf("logger.Close: %v", firstErr)
+	}
+	return nil
+}
+
+// Dropped returns the number of records dropped due to the overflow policy
+// in async mode.
+func (this *Logger) Dropped() uint64 {
+	if this.queue != nil {
+		return this.queue.Dropped()
+	}
+	return 0
+}
+
 func (this *Logger) Trace(args ...interface{}) {
 	this.Log(1, iface.Trace, args...)
 }
@@ -75,6 +166,10 @@ func (this *Logger) Tracef(fmtstr string, args ...interface{}) {
 	this.Logf(1, iface.Trace, fmtstr, args...)
 }
 
+func (this *Logger) Tracew(msg string, contexts ...iface.Context) {
+	this.Logw(1, iface.Trace, msg, contexts...)
+}
+
 func (this *Logger) Debug(args ...interface{}) {
 	this.Log(1, iface.Debug, args...)
 }
@@ -83,6 +178,10 @@ func (this *Logger) Debugf(fmtstr string, args ...interface{}) {
 	this.Logf(1, iface.Debug, fmtstr, args...)
 }
 
+func (this *Logger) Debugw(msg string, contexts ...iface.Context) {
+	this.Logw(1, iface.Debug, msg, contexts...)
+}
+
 func (this *Logger) Info(args ...interface{}) {
 	this.Log(1, iface.Info, args...)
 }
@@ -91,6 +190,10 @@ func (this *Logger) Infof(fmtstr string, args ...interface{}) {
 	this.Logf(1, iface.Info, fmtstr, args...)
 }
 
+func (this *Logger) Infow(msg string, contexts ...iface.Context) {
+	this.Logw(1, iface.Info, msg, contexts...)
+}
+
 func (this *Logger) Warn(args ...interface{}) {
 	this.Log(1, iface.Warn, args...)
 }
@@ -99,6 +202,10 @@ func (this *Logger) Warnf(fmtstr string, args ...interface{}) {
 	this.Logf(1, iface.Warn, fmtstr, args...)
 }
 
+func (this *Logger) Warnw(msg string, contexts ...iface.Context) {
+	this.Logw(1, iface.Warn, msg, contexts...)
+}
+
 func (this *Logger) Error(args ...interface{}) {
 	this.Log(1, iface.Error, args...)
 }
@@ -107,14 +214,26 @@ func (this *Logger) Errorf(fmtstr string, args ...interface{}) {
 	this.Logf(1, iface.Error, fmtstr, args...)
 }
 
+func (this *Logger) Errorw(msg string, contexts ...iface.Context) {
+	this.Logw(1, iface.Error, msg, contexts...)
+}
+
 func (this *Logger) Fatal(args ...interface{}) {
 	this.Log(1, iface.Fatal, args...)
-	os.Exit(1)
+	_ = this.Flush()
+	this.config.Exit(1)
 }
 
 func (this *Logger) Fatalf(fmtstr string, args ...interface{}) {
 	this.Logf(1, iface.Fatal, fmtstr, args...)
-	os.Exit(1)
+	_ = this.Flush()
+	this.config.Exit(1)
+}
+
+func (this *Logger) Fatalw(msg string, contexts ...iface.Context) {
+	this.Logw(1, iface.Fatal, msg, contexts...)
+	_ = this.Flush()
+	this.config.Exit(1)
 }
 
 func (this *Logger) EError(args ...interface{}) error {
@@ -123,10 +242,26 @@ func (this *Logger) EError(args ...interface{}) error {
 	return errors.New(msg)
 }
 
+// EErrorf supports the verb %w as fmt.Errorf does. If the returned error
+// wraps other errors, the chain of errors.Unwrap is logged as a context.
 func (this *Logger) EErrorf(fmtstr string, args ...interface{}) error {
-	msg := fmt.Sprintf(fmtstr, args...)
-	this.Log(1, iface.Error, msg)
-	return errors.New(msg)
+	err := fmt.Errorf(fmtstr, args...)
+	this.Logw(1, iface.Error, err.Error(), errorChain(errors.Unwrap(err))...)
+	return err
+}
+
+// EWrap returns an error that wraps the cause with the message, e.g.
+// "msg: cause", and logs it with the chain of errors.Unwrap of the cause.
+func (this *Logger) EWrap(cause error, args ...interface{}) error {
+	err := wrapError(cause, fmt.Sprint(args...))
+	this.Logw(1, iface.Error, err.Error(), errorChain(cause)...)
+	return err
+}
+
+func (this *Logger) EWrapf(cause error, fmtstr string, args ...interface{}) error {
+	err := wrapError(cause, fmt.Sprintf(fmtstr, args...))
+	this.Logw(1, iface.Error, err.Error(), errorChain(cause)...)
+	return err
 }
 
 func (this *Logger) Panic(args ...interface{}) {
@@ -142,17 +277,51 @@ func (this *Logger) Panicf(fmtstr string, args ...interface{}) {
 }
 
 func (this *Logger) Log(callDepth int, level iface.Level, args ...interface{}) {
-	if this.needToLog(level) {
-		this.log(callDepth, level, fmt.Sprint(args...))
+	if pos, ok := this.locate(callDepth, level); ok {
+		this.emit(level, &pos, fmt.Sprint(args...), nil)
 	}
 }
 
 func (this *Logger) Logf(callDepth int, level iface.Level, fmtstr string, args ...interface{}) {
+	if pos, ok := this.locate(callDepth, level); ok {
+		this.emit(level, &pos, fmt.Sprintf(fmtstr, args...), nil)
+	}
+}
+
+// Logw is like Log, but takes a message and typed contexts of the call.
+// The contexts are appended to the contexts of the Logger.
+func (this *Logger) Logw(callDepth int, level iface.Level, msg string, contexts ...iface.Context) {
+	if pos, ok := this.locate(callDepth, level); ok {
+		this.emit(level, &pos, msg, contexts)
+	}
+}
+
+// LogPC is like Logw, but the position info of the record is resolved from pc
+// instead of the call stack, and the contexts of ctx are added as LogCtx does.
+// It is intended for adapters of other logging APIs, e.g. log/slog.
+func (this *Logger) LogPC(ctx context.Context, pc uintptr, level iface.Level, msg string,
+	contexts []iface.Context) {
 	if this.needToLog(level) {
-		this.log(callDepth, level, fmt.Sprintf(fmtstr, args...))
+		pos := getPCInfo(pc)
+		if this.levelAt(&pos) <= level {
+			if this.StackLevel() <= level {
+				pos.stack = captureStackFrom(pc)
+			}
+			this.emit(level, &pos, msg, this.extract(ctx, contexts))
+		}
 	}
 }
 
+// Enabled reports whether a log of the level may be emitted by the Logger,
+// regardless of the filters. If there are level overrides (see SetVModule),
+// it reports whether the log may be emitted at some position.
+func (this *Logger) Enabled(level iface.Level) bool {
+	if level < iface.Trace || level > iface.Fatal {
+		return false
+	}
+	return this.additional.Level <= level && this.minLevel() <= level
+}
+
 func (this *Logger) Timing(level iface.Level, args ...interface{}) func() {
 	if this.needToLog(level) {
 		return this.doneFunc(level, fmt.Sprint(args...))
@@ -174,7 +343,23 @@ func (this *Logger) needToLog(level iface.Level) bool {
 		panic(fmt.Sprintf("gxlog: invalid log level: %d", level))
 	}
 
-	return this.additional.Level <= level && this.Level() <= level
+	return this.additional.Level <= level && this.minLevel() <= level
+}
+
+// locate returns the position info of the caller and whether a log of the
+// level needs to be emitted there.
+func (this *Logger) locate(callDepth int, level iface.Level) (position, bool) {
+	if !this.needToLog(level) {
+		return position{}, false
+	}
+	pos := getPosInfo(callDepth + callDepthOffset)
+	if this.levelAt(&pos) > level {
+		return pos, false
+	}
+	if this.StackLevel() <= level {
+		pos.stack = captureStack(callDepth + callDepthOffset)
+	}
+	return pos, true
 }
 
 func (this *Logger) doneFunc(level iface.Level, msg string) func() {
@@ -186,31 +371,42 @@ func (this *Logger) doneFunc(level iface.Level, msg string) func() {
 }
 
 func (this *Logger) log(callDepth int, level iface.Level, msg string) {
-	file, line, pkg, fn := getPosInfo(callDepth + callDepthOffset)
+	pos := getPosInfo(callDepth + callDepthOffset)
+	if this.levelAt(&pos) <= level {
+		if this.StackLevel() <= level {
+			pos.stack = captureStack(callDepth + callDepthOffset)
+		}
+		this.emit(level, &pos, msg, nil)
+	}
+}
 
+func (this *Logger) emit(level iface.Level, pos *position, msg string, extra []iface.Context) {
 	contexts := this.additional.Statics
 	for _, context := range this.additional.Dynamics {
-		contexts = append(contexts, iface.Context{
-			Key:   context.Key,
-			Value: fmt.Sprint(context.Value()),
-		})
+		contexts = append(contexts, iface.Any(context.Key, context.Value()))
 	}
-
-	this.lock.Lock()
+	contexts = append(contexts, extra...)
 
 	record := &iface.Record{
-		Time:     time.Now(),
 		Level:    level,
-		File:     file,
-		Line:     line,
-		Pkg:      pkg,
-		Func:     fn,
+		File:     pos.file,
+		Line:     pos.line,
+		Pkg:      pos.pkg,
+		Func:     pos.fn,
 		Msg:      msg,
+		Stack:    pos.stack,
 		Prefix:   this.additional.Prefix,
 		Contexts: contexts,
 		Mark:     this.additional.Mark,
 	}
 
+	if this.queue != nil && this.queue.Push(record, this.additional.Filter) {
+		return
+	}
+
+	this.lock.Lock()
+
+	record.Time = time.Now()
 	if this.additional.Filter(record) && this.config.Filter(record) {
 		this.formatAndWrite(level, record)
 	}
@@ -241,20 +437,41 @@ func (this *Logger) formatAndWrite(level iface.Level, record *iface.Record) {
 	}
 }
 
-func getPosInfo(callDepth int) (file string, line int, pkg, fn string) {
-	var pc uintptr
-	var ok bool
-	pc, file, line, ok = runtime.Caller(callDepth)
-	if ok {
-		name := runtime.FuncForPC(pc).Name()
-		pkg, fn = splitPkgAndFunc(name)
-	} else {
-		file = "???"
-		line = 0
-		pkg = "???"
-		fn = "???"
+type position struct {
+	pc    uintptr
+	file  string
+	line  int
+	pkg   string
+	fn    string
+	stack string
+}
+
+func getPosInfo(callDepth int) position {
+	pc, file, line, ok := runtime.Caller(callDepth)
+	if !ok {
+		return position{file: "???", pkg: "???", fn: "???"}
+	}
+	name := runtime.FuncForPC(pc).Name()
+	pkg, fn := splitPkgAndFunc(name)
+	return position{pc: pc, file: filepath.ToSlash(file), line: line, pkg: pkg, fn: fn}
+}
+
+func getPCInfo(pc uintptr) position {
+	if pc == 0 {
+		return position{file: "???", pkg: "???", fn: "???"}
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.Function == "" {
+		return position{file: "???", pkg: "???", fn: "???"}
+	}
+	pkg, fn := splitPkgAndFunc(frame.Function)
+	return position{
+		pc:   pc,
+		file: filepath.ToSlash(frame.File),
+		line: frame.Line,
+		pkg:  pkg,
+		fn:   fn,
 	}
-	return filepath.ToSlash(file), line, pkg, fn
 }
 
 func splitPkgAndFunc(name string) (string, string) {
@@ -1,6 +1,7 @@
 package logger_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
@@ -9,9 +10,11 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/gratonos/gxlog/formatter"
 	"github.com/gratonos/gxlog/formatter/text"
 	"github.com/gratonos/gxlog/iface"
 	"github.com/gratonos/gxlog/logger"
+	"github.com/gratonos/gxlog/writer"
 )
 
 type externalChecker func(log string) error
@@ -38,8 +41,220 @@ func init() {
 }
 
 func TestConcurrency(t *testing.T) {
-	writer := newTestWriter(newChecker())
-	log := newLogger(writer, t)
+	testConcurrency(t, logger.Config{}, "{{time}} [{{context}}] {{msg}}", newChecker())
+}
+
+func TestAsyncConcurrency(t *testing.T) {
+	// A Push of the async queue is so cheap that successive logs of a
+	// goroutine may share a microsecond, so the times are in nanoseconds.
+	asyncRegexp := regexp.MustCompile(fmt.Sprintf("^%s %s\\d{3} %s %s$",
+		dateRegexp, timeRegexp, contextsRegexp, msgRegexp))
+	testConcurrency(t, logger.Config{Async: true, QueueSize: 64},
+		"{{time:date.ns}} [{{context}}] {{msg}}", newRegexpChecker(asyncRegexp))
+}
+
+func TestAsyncOverflow(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	total := 0
+	writer := writer.Func(func([]byte, *iface.Record) error {
+		if total == 0 {
+			close(entered)
+			<-release
+		}
+		total++
+		return nil
+	})
+
+	log := logger.New(logger.Config{Async: true, QueueSize: 2, Overflow: logger.DropNewest})
+	log.SetSlot(logger.Slot0, logger.Slot{Formatter: formatter.Null(), Writer: writer})
+
+	log.Info("blocking")
+	<-entered
+	for i := 0; i < 5; i++ {
+		log.Info(i)
+	}
+	close(release)
+	log.Close()
+
+	if total != 3 {
+		t.Errorf("total logs, expect 3, got %d", total)
+	}
+	if dropped := log.Dropped(); dropped != 3 {
+		t.Errorf("dropped logs, expect 3, got %d", dropped)
+	}
+
+	log.Info("after close")
+	if total != 4 {
+		t.Errorf("log after close missing, expect 4, got %d", total)
+	}
+}
+
+func TestClose(t *testing.T) {
+	shared := new(lifecycleWriter)
+	flusher := new(flushWriter)
+
+	log := logger.New(logger.Config{})
+	log.SetSlot(logger.Slot0, logger.Slot{Writer: shared})
+	log.SetSlot(logger.Slot1, logger.Slot{Writer: shared})
+	log.SetSlot(logger.Slot2, logger.Slot{Writer: flusher})
+
+	if err := log.Flush(); err != nil {
+		t.Errorf("Flush: %v", err)
+	}
+	if shared.flushes != 1 || flusher.flushes != 1 {
+		t.Errorf("flushes, expect 1 and 1, got %d and %d", shared.flushes, flusher.flushes)
+	}
+
+	if err := log.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if shared.closes != 1 {
+		t.Errorf("closes, expect 1, got %d", shared.closes)
+	}
+	if flusher.flushes != 2 {
+		t.Errorf("flushes of a non-closer, expect 2, got %d", flusher.flushes)
+	}
+}
+
+func TestFatal(t *testing.T) {
+	writer := new(lifecycleWriter)
+	code := 0
+	exit := func(c int) {
+		if writer.flushes == 0 {
+			t.Error("exit before flushing")
+		}
+		code = c
+	}
+
+	log := logger.New(logger.Config{Async: true, Exit: exit})
+	defer log.Close()
+	log.SetSlot(logger.Slot0, logger.Slot{Formatter: formatter.Null(), Writer: writer})
+	log.Fatal("fatal")
+
+	if writer.writes != 1 {
+		t.Errorf("writes, expect 1, got %d", writer.writes)
+	}
+	if code != 1 {
+		t.Errorf("exit code, expect 1, got %d", code)
+	}
+}
+
+func TestTypedContexts(t *testing.T) {
+	var output string
+	log := logger.New(logger.Config{})
+	log.SetSlot(logger.Slot0, logger.Slot{
+		Formatter: text.New(text.Config{Header: "[{{context}}] {{msg}}"}),
+		Writer: writer.Func(func(bs []byte, _ *iface.Record) error {
+			output = string(bs)
+			return nil
+		}),
+	})
+
+	log.WithStatics("k1", 1).WithContexts(iface.Bool("k2", true)).
+		Infow("testing", iface.Float64("k3", 0.5))
+	expect := "[(k1: 1) (k2: true) (k3: 0.5)] testing"
+	if output != expect {
+		t.Errorf("TestTypedContexts:\noutput: %q\nexpect: %q", output, expect)
+	}
+}
+
+func TestContext(t *testing.T) {
+	var output string
+	log := logger.New(logger.Config{})
+	log.SetSlot(logger.Slot0, logger.Slot{
+		Formatter: text.New(text.Config{Header: "[{{context}}] {{msg}}"}),
+		Writer: writer.Func(func(bs []byte, _ *iface.Record) error {
+			output = string(bs)
+			return nil
+		}),
+	})
+	type tenantKey struct{}
+	log.SetExtractors(func(ctx context.Context, contexts []iface.Context) []iface.Context {
+		if tenant, ok := ctx.Value(tenantKey{}).(string); ok {
+			contexts = append(contexts, iface.String("tenant", tenant))
+		}
+		return contexts
+	})
+
+	ctx := logger.NewContext(context.Background(), log.WithStatics("k", "v"))
+	ctx = logger.AppendContexts(ctx, iface.Int("request", 1))
+	ctx = context.WithValue(ctx, tenantKey{}, "acme")
+
+	logger.FromContext(ctx).InfoCtx(ctx, "testing")
+	expect := "[(k: v) (request: 1) (tenant: acme)] testing"
+	if output != expect {
+		t.Errorf("TestContext:\noutput: %q\nexpect: %q", output, expect)
+	}
+
+	if logger.FromContext(context.Background()) != nil {
+		t.Error("TestContext: unexpected logger from an empty context")
+	}
+}
+
+func TestStack(t *testing.T) {
+	var record iface.Record
+	log := logger.New(logger.Config{CaptureStack: true, StackLevel: iface.Error})
+	log.SetSlot(logger.Slot0, logger.Slot{
+		Writer: writer.Func(func(_ []byte, r *iface.Record) error {
+			record = *r
+			return nil
+		}),
+	})
+
+	log.Warn("no stack")
+	if record.Stack != "" {
+		t.Errorf("TestStack: unexpected stack: %q", record.Stack)
+	}
+
+	log.Error("stack")
+	if !strings.HasPrefix(record.Stack, "github.com/gratonos/gxlog/logger_test.TestStack()\n") {
+		t.Errorf("TestStack: the stack does not begin with the caller: %q", record.Stack)
+	}
+
+	log.SetStackLevel(iface.Off)
+	log.Error("stack disabled")
+	if record.Stack != "" {
+		t.Errorf("TestStack: unexpected stack: %q", record.Stack)
+	}
+}
+
+func TestEWrap(t *testing.T) {
+	var output string
+	log := logger.New(logger.Config{})
+	log.SetSlot(logger.Slot0, logger.Slot{
+		Formatter: text.New(text.Config{Header: "[{{context}}] {{msg}}"}),
+		Writer: writer.Func(func(bs []byte, _ *iface.Record) error {
+			output = string(bs)
+			return nil
+		}),
+	})
+
+	root := errors.New("root")
+	cause := log.EErrorf("read: %w", root)
+	if !errors.Is(cause, root) {
+		t.Error("TestEWrap: EErrorf does not wrap the error")
+	}
+	expect := "[(chain: {0: root})] read: root"
+	if output != expect {
+		t.Errorf("TestEWrap:\noutput: %q\nexpect: %q", output, expect)
+	}
+
+	err := log.EWrap(cause, "load")
+	if !errors.Is(err, root) || err.Error() != "load: read: root" {
+		t.Errorf("TestEWrap: unexpected error: %v", err)
+	}
+	expect = `[(chain: {0: "read: root", 1: root})] load: read: root`
+	if output != expect {
+		t.Errorf("TestEWrap:\noutput: %q\nexpect: %q", output, expect)
+	}
+}
+
+// testConcurrency logs from goroutines concurrently with a logger of the
+// config, and checks each log by the checker.
+func testConcurrency(t *testing.T, config logger.Config, header string, checker externalChecker) {
+	writer := newTestWriter(checker)
+	log := newLogger(config, header, writer, t)
 
 	var wg sync.WaitGroup
 	for i := 0; i < goroutines; i++ {
@@ -58,6 +273,7 @@ func TestConcurrency(t *testing.T) {
 	}
 
 	wg.Wait()
+	log.Flush()
 
 	expectLogs := goroutines * logCount
 	gotLogs := writer.TotalLogs()
@@ -67,6 +283,10 @@ func TestConcurrency(t *testing.T) {
 }
 
 func newChecker() externalChecker {
+	return newRegexpChecker(logRegexp)
+}
+
+func newRegexpChecker(logRegexp *regexp.Regexp) externalChecker {
 	return func(log string) error {
 		indexes := logRegexp.FindStringSubmatchIndex(log)
 		if indexes == nil {
@@ -94,16 +314,17 @@ func newChecker() externalChecker {
 	}
 }
 
-func newLogger(writer iface.Writer, t *testing.T) *logger.Logger {
+func newLogger(config logger.Config, header string, writer iface.Writer,
+	t *testing.T) *logger.Logger {
 	formatter := text.New(text.Config{
-		Header: "{{time}} [{{context}}] {{msg}}",
+		Header: header,
 	})
 
 	handler := func(_ []byte, _ *iface.Record, err error) {
 		t.Errorf("%v", err)
 	}
 
-	log := logger.New(logger.Config{})
+	log := logger.New(config)
 	log.SetSlot(logger.Slot0, logger.Slot{
 		Formatter:    formatter,
 		Writer:       writer,
@@ -0,0 +1,226 @@
+package logger
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gratonos/gxlog/iface"
+)
+
+// The buckets of a RateLimiter that have been refilled are pruned when the
+// number of buckets exceeds it.
+const maxBuckets = 4096
+
+// The counts of a Sampler without an interval are reset when the number of
+// groups exceeds it.
+const maxCounts = 4096
+
+// A KeyFunc returns the key to group records for sampling or rate limiting.
+// If a KeyFunc is nil, records are grouped by Record.File and Record.Line.
+// Records of different levels are always in different groups.
+type KeyFunc func(record *iface.Record) string
+
+// MsgKey groups records by their messages.
+func MsgKey(record *iface.Record) string {
+	return record.Msg
+}
+
+type groupKey struct {
+	level iface.Level
+	file  string
+	line  int
+	key   string
+}
+
+func makeGroupKey(keyFunc KeyFunc, record *iface.Record) groupKey {
+	if keyFunc == nil {
+		return groupKey{level: record.Level, file: record.File, line: record.Line}
+	}
+	return groupKey{level: record.Level, key: keyFunc(record)}
+}
+
+// A Sampler lets the first N records of each group pass in every interval and
+// then every Mth one. The method Filter of a Sampler is a Filter, e.g.
+// Config{Filter: sampler.Filter}, and it is concurrency safe.
+type Sampler struct {
+	suppressed uint64 // accessed atomically, keep it 64-bit aligned
+
+	first      int
+	thereafter int
+	interval   time.Duration
+	keyFunc    KeyFunc
+
+	counts    map[groupKey]int
+	windowEnd time.Time
+	lock      sync.Mutex
+}
+
+// NewSampler returns a Sampler. If thereafter is NOT positive, all the records
+// after the first N of a group are suppressed. If interval is NOT positive,
+// the counts of groups are NOT reset periodically but only when the number of
+// groups exceeds 4096, which bounds the memory.
+func NewSampler(first, thereafter int, interval time.Duration, keyFunc KeyFunc) *Sampler {
+	return &Sampler{
+		first:      first,
+		thereafter: thereafter,
+		interval:   interval,
+		keyFunc:    keyFunc,
+		counts:     make(map[groupKey]int),
+	}
+}
+
+func (this *Sampler) Filter(record *iface.Record) bool {
+	key := makeGroupKey(this.keyFunc, record)
+
+	this.lock.Lock()
+
+	if this.interval > 0 && !record.Time.Before(this.windowEnd) {
+		this.counts = make(map[groupKey]int)
+		this.windowEnd = record.Time.Add(this.interval)
+	} else if this.interval <= 0 && len(this.counts) >= maxCounts {
+		if _, ok := this.counts[key]; !ok {
+			this.counts = make(map[groupKey]int)
+		}
+	}
+	n := this.counts[key] + 1
+	this.counts[key] = n
+
+	this.lock.Unlock()
+
+	if n <= this.first || (this.thereafter > 0 && (n-this.first)%this.thereafter == 0) {
+		return true
+	}
+	atomic.AddUint64(&this.suppressed, 1)
+	return false
+}
+
+// Suppressed returns the number of records suppressed by the Sampler.
+func (this *Sampler) Suppressed() uint64 {
+	return atomic.LoadUint64(&this.suppressed)
+}
+
+// A Limit is the parameter of a token bucket.
+type Limit struct {
+	Rate  float64 // tokens per second
+	Burst int
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// A RateLimiter limits the rate of records of each group with a token bucket
+// per group. The method Filter of a RateLimiter is a Filter, e.g.
+// Slot{Filter: limiter.Filter}, and it is concurrency safe.
+type RateLimiter struct {
+	suppressed uint64 // accessed atomically, keep it 64-bit aligned
+
+	limits  [iface.LogLevelCount]Limit
+	limited [iface.LogLevelCount]bool
+	keyFunc KeyFunc
+
+	buckets map[groupKey]*bucket
+	lock    sync.Mutex
+}
+
+// NewRateLimiter returns a RateLimiter. Records of the levels NOT in limitMap
+// are NOT limited. The levels in limitMap MUST be in the range of Trace to
+// Fatal, or it panics.
+func NewRateLimiter(limitMap map[iface.Level]Limit, keyFunc KeyFunc) *RateLimiter {
+	limiter := &RateLimiter{
+		keyFunc: keyFunc,
+		buckets: make(map[groupKey]*bucket),
+	}
+	for level, limit := range limitMap {
+		if level < iface.Trace || level > iface.Fatal {
+			panic(fmt.Sprintf("gxlog: invalid log level of limit: %d", level))
+		}
+		limiter.limits[level] = limit
+		limiter.limited[level] = true
+	}
+	return limiter
+}
+
+func (this *RateLimiter) Filter(record *iface.Record) bool {
+	if record.Level < iface.Trace || record.Level > iface.Fatal || !this.limited[record.Level] {
+		return true
+	}
+	limit := this.limits[record.Level]
+	key := makeGroupKey(this.keyFunc, record)
+
+	this.lock.Lock()
+
+	b := this.buckets[key]
+	if b == nil {
+		if len(this.buckets) >= maxBuckets {
+			this.prune(record.Time)
+		}
+		b = &bucket{tokens: float64(limit.Burst), last: record.Time}
+		this.buckets[key] = b
+	} else if elapsed := record.Time.Sub(b.last); elapsed > 0 {
+		b.tokens = refill(b.tokens, elapsed, limit)
+		b.last = record.Time
+	}
+	ok := b.tokens >= 1
+	if ok {
+		b.tokens--
+	}
+
+	this.lock.Unlock()
+
+	if !ok {
+		atomic.AddUint64(&this.suppressed, 1)
+	}
+	return ok
+}
+
+// Suppressed returns the number of records suppressed by the RateLimiter.
+func (this *RateLimiter) Suppressed() uint64 {
+	return atomic.LoadUint64(&this.suppressed)
+}
+
+func (this *RateLimiter) prune(now time.Time) {
+	for key, b := range this.buckets {
+		limit := this.limits[key.level]
+		if refill(b.tokens, now.Sub(b.last), limit) >= float64(limit.Burst) {
+			delete(this.buckets, key)
+		}
+	}
+}
+
+func refill(tokens float64, elapsed time.Duration, limit Limit) float64 {
+	tokens += elapsed.Seconds() * limit.Rate
+	if burst := float64(limit.Burst); tokens > burst {
+		tokens = burst
+	}
+	return tokens
+}
+
+// A RandomSampler lets records pass with a probability. The method Filter of
+// a RandomSampler is a Filter and it is concurrency safe.
+type RandomSampler struct {
+	suppressed uint64 // accessed atomically, keep it 64-bit aligned
+
+	probability float64
+}
+
+func NewRandomSampler(probability float64) *RandomSampler {
+	return &RandomSampler{probability: probability}
+}
+
+func (this *RandomSampler) Filter(*iface.Record) bool {
+	if rand.Float64() < this.probability {
+		return true
+	}
+	atomic.AddUint64(&this.suppressed, 1)
+	return false
+}
+
+// Suppressed returns the number of records suppressed by the RandomSampler.
+func (this *RandomSampler) Suppressed() uint64 {
+	return atomic.LoadUint64(&this.suppressed)
+}
@@ -0,0 +1,122 @@
+package logger_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gratonos/gxlog/iface"
+	"github.com/gratonos/gxlog/logger"
+)
+
+func TestSampler(t *testing.T) {
+	sampler := logger.NewSampler(2, 3, time.Second, nil)
+	now := time.Now()
+	record := &iface.Record{Time: now, Level: iface.Info, File: "a.go", Line: 1}
+
+	var passed []int
+	for i := 1; i <= 10; i++ {
+		if sampler.Filter(record) {
+			passed = append(passed, i)
+		}
+	}
+	expect := []int{1, 2, 5, 8}
+	if !equalInts(passed, expect) {
+		t.Errorf("TestSampler: expect %v, got %v", expect, passed)
+	}
+	if n := sampler.Suppressed(); n != 6 {
+		t.Errorf("TestSampler: suppressed, expect 6, got %d", n)
+	}
+
+	other := &iface.Record{Time: now, Level: iface.Info, File: "a.go", Line: 2}
+	if !sampler.Filter(other) {
+		t.Error("TestSampler: another call site is suppressed")
+	}
+	record.Time = now.Add(time.Second)
+	if !sampler.Filter(record) {
+		t.Error("TestSampler: counts are not reset after the interval")
+	}
+}
+
+func TestSamplerWithoutInterval(t *testing.T) {
+	sampler := logger.NewSampler(1, 0, 0, nil)
+	record := &iface.Record{Level: iface.Info, File: "a.go"}
+	if !sampler.Filter(record) || sampler.Filter(record) {
+		t.Fatal("TestSamplerWithoutInterval: unexpected result")
+	}
+	for line := 1; line <= 4096; line++ {
+		sampler.Filter(&iface.Record{Level: iface.Info, File: "b.go", Line: line})
+	}
+	if !sampler.Filter(record) {
+		t.Error("TestSamplerWithoutInterval: counts are not reset beyond the max groups")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := logger.NewRateLimiter(map[iface.Level]logger.Limit{
+		iface.Info: {Rate: 10, Burst: 2},
+	}, logger.MsgKey)
+	now := time.Now()
+	record := &iface.Record{Time: now, Level: iface.Info, Msg: "hot"}
+
+	for i := 0; i < 2; i++ {
+		if !limiter.Filter(record) {
+			t.Errorf("TestRateLimiter: record %d within the burst is suppressed", i)
+		}
+	}
+	if limiter.Filter(record) {
+		t.Error("TestRateLimiter: record beyond the burst passed")
+	}
+	record.Time = now.Add(100 * time.Millisecond)
+	if !limiter.Filter(record) {
+		t.Error("TestRateLimiter: token is not refilled")
+	}
+	if limiter.Filter(record) {
+		t.Error("TestRateLimiter: token is refilled too much")
+	}
+	if n := limiter.Suppressed(); n != 2 {
+		t.Errorf("TestRateLimiter: suppressed, expect 2, got %d", n)
+	}
+
+	record.Level = iface.Error
+	for i := 0; i < 10; i++ {
+		if !limiter.Filter(record) {
+			t.Error("TestRateLimiter: unlimited level is suppressed")
+		}
+	}
+}
+
+func TestRateLimiterInvalidLevel(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("TestRateLimiterInvalidLevel: no panic")
+		}
+	}()
+	logger.NewRateLimiter(map[iface.Level]logger.Limit{iface.Off: {Rate: 1, Burst: 1}}, nil)
+}
+
+func TestRandomSampler(t *testing.T) {
+	record := &iface.Record{}
+	none := logger.NewRandomSampler(0)
+	all := logger.NewRandomSampler(1)
+	for i := 0; i < 100; i++ {
+		if none.Filter(record) || !all.Filter(record) {
+			t.Fatal("TestRandomSampler: unexpected result")
+		}
+	}
+	if none.Suppressed() != 100 || all.Suppressed() != 0 {
+		t.Errorf("TestRandomSampler: suppressed, expect 100 and 0, got %d and %d",
+			none.Suppressed(), all.Suppressed())
+	}
+}
+
+func equalInts(left, right []int) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for i := range left {
+		if left[i] != right[i] {
+			return false
+		}
+	}
+	return true
+}
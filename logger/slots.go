@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"fmt"
 	"reflect"
 
 	"github.com/gratonos/gxlog/formatter"
@@ -80,6 +81,36 @@ func (this *Logger) ResetAllSlots() {
 	this.updateEquivalents()
 }
 
+// Reconfigure sets the level, the level overrides (see SetVModule) and all
+// the slots of the Logger at once under the lock, so that a log is formatted
+// and written either by all the previous slots or by all the new ones. A nil
+// slot or a slot beyond len(slots) is reset. Nothing is changed if the spec
+// is invalid.
+func (this *Logger) Reconfigure(level iface.Level, spec string, slots []*Slot) error {
+	if len(slots) > MaxSlot {
+		return fmt.Errorf("logger.Reconfigure: too many slots: %d > %d", len(slots), MaxSlot)
+	}
+	vm, err := parseVModule(spec)
+	if err != nil {
+		return fmt.Errorf("logger.Reconfigure: %v", err)
+	}
+
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	this.SetLevel(level)
+	this.vmodule.Store(vm)
+	for i := range this.slots {
+		if i < len(slots) && slots[i] != nil {
+			this.slots[i] = fillSlot(*slots[i])
+		} else {
+			this.slots[i] = nullSlot
+		}
+	}
+	this.updateEquivalents()
+	return nil
+}
+
 func (this *Logger) CopySlot(dst, src SlotIndex) {
 	this.lock.Lock()
 	defer this.lock.Unlock()
@@ -190,6 +221,17 @@ func (this *Logger) updateEquivalents() {
 	}
 }
 
+func (this *Logger) uniqueWriters() []iface.Writer {
+	writers := make([]iface.Writer, 0, MaxSlot)
+	for i := 0; i < MaxSlot; i++ {
+		writer := this.slots[i].Writer
+		if !containsWriter(writers, writer) {
+			writers = append(writers, writer)
+		}
+	}
+	return writers
+}
+
 func initSlots() []Slot {
 	slots := make([]Slot, MaxSlot)
 	for i := range slots {
@@ -219,3 +261,16 @@ func fillWriter(writer iface.Writer) iface.Writer {
 	}
 	return writer
 }
+
+func containsWriter(writers []iface.Writer, writer iface.Writer) bool {
+	if !reflect.TypeOf(writer).Comparable() {
+		return false
+	}
+	for _, w := range writers {
+		// different dynamic types never panic when compared
+		if w == writer {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+const maxStackDepth = 64
+
+// captureStack returns the formatted call stack. The skip is the number of
+// frames to skip as runtime.Caller does, with 0 identifying captureStack itself.
+func captureStack(skip int) string {
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(skip+1, pcs[:])
+	return formatStack(pcs[:n])
+}
+
+// captureStackFrom returns the formatted call stack beginning with the frame
+// of pc, or the whole stack if pc is NOT found.
+func captureStackFrom(pc uintptr) string {
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(2, pcs[:])
+	stack := pcs[:n]
+	for i, p := range stack {
+		if p == pc {
+			stack = stack[i:]
+			break
+		}
+	}
+	return formatStack(stack)
+}
+
+// formatStack formats a stack in the same way as a panic does, e.g.
+// "main.main()\n\t/home/test/main.go:10\n".
+func formatStack(pcs []uintptr) string {
+	if len(pcs) == 0 {
+		return ""
+	}
+	var builder strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&builder, "%s()\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return builder.String()
+}
@@ -36,3 +36,32 @@ func (this *testWriter) Write(bs []byte, _ *iface.Record) error {
 func (this *testWriter) TotalLogs() int {
 	return this.totalLogs
 }
+
+type flushWriter struct {
+	flushes int
+}
+
+func (this *flushWriter) Write([]byte, *iface.Record) error {
+	return nil
+}
+
+func (this *flushWriter) Flush() error {
+	this.flushes++
+	return nil
+}
+
+type lifecycleWriter struct {
+	flushWriter
+	writes int
+	closes int
+}
+
+func (this *lifecycleWriter) Write([]byte, *iface.Record) error {
+	this.writes++
+	return nil
+}
+
+func (this *lifecycleWriter) Close() error {
+	this.closes++
+	return nil
+}
@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/gratonos/gxlog/iface"
+)
+
+type vmoduleRule struct {
+	pattern string
+	segs    int
+	level   iface.Level
+}
+
+// A vmodule is a table of level overrides, which is immutable except its cache.
+type vmodule struct {
+	spec     string
+	rules    []vmoduleRule
+	minLevel iface.Level
+	cache    sync.Map // pc -> iface.Level, Off+1 if no rule matches
+}
+
+// VModule returns the spec of the level overrides of the Logger.
+func (this *Logger) VModule() string {
+	if vm := this.vmodule.Load().(*vmodule); vm != nil {
+		return vm.spec
+	}
+	return ""
+}
+
+// SetVModule sets the level overrides of the Logger and its derivations.
+// The spec is a comma-separated list of pattern=level, e.g.
+// "github.com/acme/db/*=trace,handler.go=debug". A pattern is matched by
+// path.Match against the last segments of the Pkg and the File of a record,
+// where the number of segments is the same as the pattern. Thus, "handler.go"
+// matches any file named handler.go and "db/*" matches any package in a
+// directory named db. The first matched rule overrides the level of the Logger.
+// An empty spec removes all the level overrides.
+func (this *Logger) SetVModule(spec string) error {
+	vm, err := parseVModule(spec)
+	if err != nil {
+		return fmt.Errorf("logger.SetVModule: %v", err)
+	}
+	this.vmodule.Store(vm)
+	return nil
+}
+
+// minLevel returns the lowest level that may be emitted at some position.
+func (this *Logger) minLevel() iface.Level {
+	level := this.Level()
+	if vm := this.vmodule.Load().(*vmodule); vm != nil && vm.minLevel < level {
+		level = vm.minLevel
+	}
+	return level
+}
+
+// levelAt returns the level of the Logger at the position.
+func (this *Logger) levelAt(pos *position) iface.Level {
+	if vm := this.vmodule.Load().(*vmodule); vm != nil {
+		if level, ok := vm.Match(pos); ok {
+			return level
+		}
+	}
+	return this.Level()
+}
+
+func parseVModule(spec string) (*vmodule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	vm := &vmodule{
+		spec:     spec,
+		minLevel: iface.Off,
+	}
+	for _, item := range strings.Split(spec, ",") {
+		pattern, levelName, ok := strings.Cut(item, "=")
+		pattern = strings.Trim(strings.TrimSpace(pattern), "/")
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("invalid rule %q", item)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+		level, err := iface.ParseLevel(levelName)
+		if err != nil {
+			return nil, fmt.Errorf("invalid level of rule %q", item)
+		}
+		vm.rules = append(vm.rules, vmoduleRule{
+			pattern: pattern,
+			segs:    strings.Count(pattern, "/") + 1,
+			level:   level,
+		})
+		if level < vm.minLevel {
+			vm.minLevel = level
+		}
+	}
+	return vm, nil
+}
+
+func (this *vmodule) Match(pos *position) (iface.Level, bool) {
+	if pos.pc != 0 {
+		if level, ok := this.cache.Load(pos.pc); ok {
+			level := level.(iface.Level)
+			return level, level <= iface.Off
+		}
+	}
+
+	level := iface.Off + 1
+	for _, rule := range this.rules {
+		if matchSegments(rule, pos.pkg) || matchSegments(rule, pos.file) {
+			level = rule.level
+			break
+		}
+	}
+
+	if pos.pc != 0 {
+		this.cache.Store(pos.pc, level)
+	}
+	return level, level <= iface.Off
+}
+
+func matchSegments(rule vmoduleRule, name string) bool {
+	n := rule.segs
+	for i := len(name) - 1; i >= 0; i-- {
+		if name[i] == '/' {
+			n--
+			if n == 0 {
+				name = name[i+1:]
+				break
+			}
+		}
+	}
+	ok, _ := path.Match(rule.pattern, name)
+	return ok
+}
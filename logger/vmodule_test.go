@@ -0,0 +1,71 @@
+package logger_test
+
+import (
+	"testing"
+
+	"github.com/gratonos/gxlog/iface"
+	"github.com/gratonos/gxlog/logger"
+	"github.com/gratonos/gxlog/writer"
+)
+
+func TestVModule(t *testing.T) {
+	total := 0
+	log := logger.New(logger.Config{Level: iface.Info})
+	log.SetSlot(logger.Slot0, logger.Slot{
+		Writer: writer.Func(func([]byte, *iface.Record) error {
+			total++
+			return nil
+		}),
+	})
+
+	evaluated := false
+	lazy := lazyString(func() string {
+		evaluated = true
+		return "lazy"
+	})
+
+	log.Trace(lazy)
+	if total != 0 || evaluated {
+		t.Fatal("TestVModule: trace is emitted or evaluated without overrides")
+	}
+
+	if err := log.SetVModule("gxlog/*=warn, vmodule_test.go=trace"); err != nil {
+		t.Fatal(err)
+	}
+	log.Trace(lazy)
+	if total != 0 || evaluated {
+		t.Fatal("TestVModule: the first matched rule does not take effect")
+	}
+
+	if err := log.SetVModule("vmodule_test.go=trace"); err != nil {
+		t.Fatal(err)
+	}
+	log.Trace(lazy)
+	if total != 1 || !evaluated {
+		t.Fatal("TestVModule: trace is not emitted with an override")
+	}
+	if log.VModule() != "vmodule_test.go=trace" {
+		t.Errorf("TestVModule: unexpected spec: %q", log.VModule())
+	}
+
+	if err := log.SetVModule("x.go=verbose"); err == nil {
+		t.Error("TestVModule: invalid level is accepted")
+	}
+	if err := log.SetVModule("[=info"); err == nil {
+		t.Error("TestVModule: invalid pattern is accepted")
+	}
+
+	if err := log.SetVModule(""); err != nil {
+		t.Fatal(err)
+	}
+	log.Trace("trace")
+	if total != 1 {
+		t.Error("TestVModule: overrides are not removed")
+	}
+}
+
+type lazyString func() string
+
+func (self lazyString) String() string {
+	return self()
+}
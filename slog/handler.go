@@ -0,0 +1,126 @@
+// Package slog implements a log/slog Handler backed by a Logger.
+package slog
+
+import (
+	"context"
+	stdslog "log/slog"
+
+	"github.com/gratonos/gxlog/iface"
+	"github.com/gratonos/gxlog/logger"
+)
+
+// A Handler passes records of log/slog to a Logger. Attributes are mapped
+// onto typed contexts. The keys of attributes in groups are qualified by the
+// group names with dots, e.g. "request.id". The time of a slog record is
+// ignored and the time when the Logger emits the record is used instead.
+type Handler struct {
+	logger *logger.Logger
+	group  string // e.g. "g1.g2."
+}
+
+func New(logger *logger.Logger) *Handler {
+	return &Handler{logger: logger}
+}
+
+func (this *Handler) Enabled(_ context.Context, level stdslog.Level) bool {
+	return this.logger.Enabled(MapLevel(level))
+}
+
+// Handle also adds the contexts of ctx to the record, see Logger.LogCtx.
+func (this *Handler) Handle(ctx context.Context, record stdslog.Record) error {
+	contexts := make([]iface.Context, 0, record.NumAttrs())
+	record.Attrs(func(attr stdslog.Attr) bool {
+		contexts = appendAttr(contexts, this.group, attr)
+		return true
+	})
+	this.logger.LogPC(ctx, record.PC, MapLevel(record.Level), record.Message, contexts)
+	return nil
+}
+
+func (this *Handler) WithAttrs(attrs []stdslog.Attr) stdslog.Handler {
+	if len(attrs) == 0 {
+		return this
+	}
+	var contexts []iface.Context
+	for _, attr := range attrs {
+		contexts = appendAttr(contexts, this.group, attr)
+	}
+	return &Handler{
+		logger: this.logger.WithContexts(contexts...),
+		group:  this.group,
+	}
+}
+
+func (this *Handler) WithGroup(name string) stdslog.Handler {
+	if name == "" {
+		return this
+	}
+	return &Handler{
+		logger: this.logger,
+		group:  this.group + name + ".",
+	}
+}
+
+// MapLevel maps a level of log/slog onto the nearest lower level of gxlog.
+// Levels higher than or equal to slog.LevelError+4 are mapped onto iface.Fatal.
+func MapLevel(level stdslog.Level) iface.Level {
+	switch {
+	case level < stdslog.LevelDebug:
+		return iface.Trace
+	case level < stdslog.LevelInfo:
+		return iface.Debug
+	case level < stdslog.LevelWarn:
+		return iface.Info
+	case level < stdslog.LevelError:
+		return iface.Warn
+	case level < stdslog.LevelError+4:
+		return iface.Error
+	default:
+		return iface.Fatal
+	}
+}
+
+func appendAttr(contexts []iface.Context, group string, attr stdslog.Attr) []iface.Context {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(stdslog.Attr{}) {
+		return contexts
+	}
+	if attr.Value.Kind() == stdslog.KindGroup {
+		attrs := attr.Value.Group()
+		if len(attrs) == 0 {
+			return contexts
+		}
+		if attr.Key != "" {
+			group += attr.Key + "."
+		}
+		for _, attr := range attrs {
+			contexts = appendAttr(contexts, group, attr)
+		}
+		return contexts
+	}
+	return append(contexts, iface.Context{
+		Key:   group + attr.Key,
+		Value: mapValue(attr.Value),
+	})
+}
+
+func mapValue(value stdslog.Value) iface.Value {
+	switch value.Kind() {
+	case stdslog.KindString:
+		return iface.StringValue(value.String())
+	case stdslog.KindInt64:
+		return iface.Int64Value(value.Int64())
+	case stdslog.KindUint64:
+		return iface.Uint64Value(value.Uint64())
+	case stdslog.KindFloat64:
+		return iface.Float64Value(value.Float64())
+	case stdslog.KindBool:
+		return iface.BoolValue(value.Bool())
+	case stdslog.KindDuration:
+		return iface.DurationValue(value.Duration())
+	case stdslog.KindTime:
+		return iface.TimeValue(value.Time())
+	default:
+		return iface.AnyValue(value.Any())
+	}
+}
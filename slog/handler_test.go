@@ -0,0 +1,61 @@
+package slog_test
+
+import (
+	"fmt"
+	stdslog "log/slog"
+	"runtime"
+	"testing"
+
+	"github.com/gratonos/gxlog/formatter/text"
+	"github.com/gratonos/gxlog/iface"
+	"github.com/gratonos/gxlog/logger"
+	"github.com/gratonos/gxlog/slog"
+	"github.com/gratonos/gxlog/writer"
+)
+
+func TestHandler(t *testing.T) {
+	var output string
+	log := logger.New(logger.Config{})
+	log.SetSlot(logger.Slot0, logger.Slot{
+		Formatter: text.New(text.Config{
+			Header: "{{level:char}} {{file:1}}:{{line}} {{func}} [{{context}}] {{msg}}",
+		}),
+		Writer: writer.Func(func(bs []byte, _ *iface.Record) error {
+			output = string(bs)
+			return nil
+		}),
+	})
+
+	slogger := stdslog.New(slog.New(log)).With("k1", 1).WithGroup("g")
+	_, _, line, _ := runtime.Caller(0)
+	slogger.Warn("testing", "k2", true, stdslog.Group("sub", "k3", "v3"))
+
+	expect := fmt.Sprintf("W handler_test.go:%d TestHandler "+
+		"[(k1: 1) (g.k2: true) (g.sub.k3: v3)] testing", line+1)
+	if output != expect {
+		t.Errorf("TestHandler:\noutput: %q\nexpect: %q", output, expect)
+	}
+
+	log.SetLevel(iface.Error)
+	output = ""
+	slogger.Info("disabled")
+	if output != "" {
+		t.Errorf("TestHandler: disabled level is output: %q", output)
+	}
+}
+
+func TestMapLevel(t *testing.T) {
+	cases := map[stdslog.Level]iface.Level{
+		stdslog.LevelDebug - 1: iface.Trace,
+		stdslog.LevelDebug:     iface.Debug,
+		stdslog.LevelInfo:      iface.Info,
+		stdslog.LevelWarn + 1:  iface.Warn,
+		stdslog.LevelError:     iface.Error,
+		stdslog.LevelError + 4: iface.Fatal,
+	}
+	for level, expect := range cases {
+		if got := slog.MapLevel(level); got != expect {
+			t.Errorf("MapLevel(%v): expect %d, got %d", level, expect, got)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// A SyncPolicy specifies when log files are synced to the storage by fsync.
+type SyncPolicy int
+
+const (
+	SyncNever SyncPolicy = iota
+	SyncOnRotation
+	SyncPeriodically // every SyncInterval
+	SyncAlways       // after every Write
+)
+
+// Flush writes the buffered data to the current file. It also syncs the file
+// unless the SyncPolicy is SyncNever.
+func (this *Writer) Flush() error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if err := this.flush(this.syncPolicy != SyncNever); err != nil {
+		return fmt.Errorf("writer/file.Flush: %v", err)
+	}
+	return nil
+}
+
+// flush MUST be called with the lock held.
+func (this *Writer) flush(sync bool) error {
+	if this.file == nil {
+		return nil
+	}
+	if this.buf != nil {
+		if err := this.buf.Flush(); err != nil {
+			return err
+		}
+	}
+	if sync {
+		return this.file.Sync()
+	}
+	return nil
+}
+
+// startFlusher MUST be called with the lock held. The flusher is stopped by
+// Close.
+func (this *Writer) startFlusher() {
+	flushes := this.buf != nil && this.flushInterval > 0
+	syncs := this.syncPolicy == SyncPeriodically && this.syncInterval > 0
+	if this.flusherDone != nil || (!flushes && !syncs) {
+		return
+	}
+	this.flusherDone = make(chan struct{})
+	this.flushers.Add(1)
+	go this.flusher(this.flusherDone, flushes, syncs)
+}
+
+func (this *Writer) flusher(done <-chan struct{}, flushes, syncs bool) {
+	defer this.flushers.Done()
+
+	var flushTicks, syncTicks <-chan time.Time
+	if flushes {
+		ticker := time.NewTicker(this.flushInterval)
+		defer ticker.Stop()
+		flushTicks = ticker.C
+	}
+	if syncs {
+		ticker := time.NewTicker(this.syncInterval)
+		defer ticker.Stop()
+		syncTicks = ticker.C
+	}
+
+	for {
+		var err error
+		select {
+		case <-done:
+			return
+		case <-flushTicks:
+			this.lock.Lock()
+			err = this.flush(false)
+			this.lock.Unlock()
+		case <-syncTicks:
+			this.lock.Lock()
+			err = this.flush(false)
+			file := this.file
+			this.lock.Unlock()
+			// fsync may be slow, thus it does NOT block Write
+			if err == nil && file != nil {
+				if err = file.Sync(); errors.Is(err, os.ErrClosed) {
+					err = nil // synced by the rotation or the Close
+				}
+			}
+		}
+		if err != nil {
+			this.errorHandler(fmt.Errorf("writer/file.flusher: %v", err))
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package file
+
+import (
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	gos "github.com/gratonos/goutil/os"
+)
+
+const tmpExtension = ".tmp"
+
+// A Compressor compresses rotated log files, e.g. a zstd compressor may be
+// implemented with a third-party package.
+type Compressor interface {
+	// Extension returns the extension appended to compressed files, e.g. ".gz".
+	Extension() string
+	// Compress compresses the content of src into dst.
+	Compress(dst io.Writer, src io.Reader) error
+}
+
+type gzipCompressor struct {
+	level int
+}
+
+// Gzip returns a Compressor with the compression level of the gzip package,
+// e.g. gzip.DefaultCompression.
+func Gzip(level int) Compressor {
+	return gzipCompressor{level: level}
+}
+
+func (self gzipCompressor) Extension() string {
+	return ".gz"
+}
+
+func (self gzipCompressor) Compress(dst io.Writer, src io.Reader) error {
+	writer, err := gzip.NewWriterLevel(dst, self.level)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(writer, src); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// compressAll compresses all the rotated log files in dir. It also recovers
+// from a crash during compression: a temporary file is removed, and a log file
+// is compressed again if it is left after its compressed file is renamed from
+// the temporary file, which only leads to duplicate logs. The current is the
+// file being written when the compression is triggered, see removeRotated.
+func (this *Writer) compressAll(dir, current string, compressor Compressor) error {
+	ext := compressor.Extension()
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		switch {
+		case entry.IsDir():
+			if !this.namer.MatchDir(rel) {
+				return filepath.SkipDir
+			}
+		case !entry.Type().IsRegular() || path == current:
+			// skip
+		case strings.HasSuffix(rel, ext+tmpExtension):
+			if this.namer.MatchCompressed(strings.TrimSuffix(rel, ext+tmpExtension)) {
+				return gos.RemoveIfExists(path)
+			}
+		case this.namer.MatchFile(rel):
+			return this.compressFile(path, compressor)
+		}
+		return nil
+	})
+}
+
+// compressFile compresses src into src+ext, or src+".<n>"+ext if the former
+// exists, e.g. the name of src is reused by a pattern without {{time}} and
+// {{seq}}. src is removed only after its content is in the compressed file.
+func (this *Writer) compressFile(src string, compressor Compressor) error {
+	ext := compressor.Extension()
+	dst := src + ext
+	for n := 1; ; n++ {
+		if ok, err := gos.FileExists(dst); err != nil {
+			return err
+		} else if !ok {
+			break
+		}
+		dst = src + "." + strconv.Itoa(n) + ext
+	}
+
+	srcFile, err := os.Open(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer srcFile.Close()
+	info, err := srcFile.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp := dst + tmpExtension
+	tmpFile, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, filePerm)
+	if err != nil {
+		return err
+	}
+	err = compressor.Compress(tmpFile, srcFile)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		// keep the modification time for the retention
+		err = os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	}
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	removed, err := this.removeRotated(src, gos.RemoveIfExists)
+	if err == nil && !removed {
+		// src is opened again by a rotation and may be appended
+		err = os.Remove(dst)
+	}
+	return err
+}
@@ -5,11 +5,77 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"time"
+
+	"github.com/gratonos/gxlog/iface"
 )
 
 type Config struct {
 	Dir         string
 	MaxFileSize int64
+
+	// Pattern is the path of log files relative to Dir, which is slash-separated
+	// and consists of literals and the elements as follows:
+	//   {{app}}  the App
+	//   {{pid}}  the process id
+	//   {{date}} the date of the first record of a file, e.g. 20261018
+	//   {{time}} the time of the first record of a file, e.g. 101500.000000
+	//   {{seq}}  the sequence number of a file in a rotation period, from 0
+	// The pattern MUST have {{time}} or {{seq}} unless the MaxFileSize is
+	// negative. The default is "{{date}}/{{time}}.log".
+	Pattern string
+	// App is the application name in the Pattern. The default is the base
+	// name of the program.
+	App string
+	// Period is the rotation period, e.g. Hourly, Daily (default), Weekly and
+	// 15 * time.Minute. The periods of days begin at midnight, weeks begin on
+	// Monday and the periods within a day begin at every midnight.
+	Period time.Duration
+	// Location is the time zone of the periods and the names of log files.
+	// The default is time.Local.
+	Location *time.Location
+	// Symlink is the path of a symlink relative to Dir, which is updated to
+	// the current log file after each rotation by the background janitor,
+	// e.g. "app.log". There is no symlink if it is empty.
+	Symlink string
+
+	// The retention of log files in Dir, which is enforced by the background
+	// janitor after each rotation. A zero value means no limit.
+	// MaxAge is the max age of log files by their modification times.
+	MaxAge time.Duration
+	// MaxFiles is the max number of log files. The oldest ones are removed.
+	MaxFiles int
+	// MaxTotalSize is the max total bytes of log files. The oldest ones are
+	// removed.
+	MaxTotalSize int64
+
+	// Compressor compresses rotated log files asynchronously if it is NOT nil,
+	// e.g. Gzip(gzip.DefaultCompression). The retention of log files is based
+	// on the compressed sizes. A suffix of ".<n>" is added to the name of a
+	// compressed file if the name is taken, e.g. "20261018.log.1.gz".
+	Compressor Compressor
+
+	// BufferSize is the size of the write buffer. Writes are NOT buffered if
+	// it is NOT positive.
+	BufferSize int
+	// FlushInterval is the interval to flush the buffer in background. The
+	// buffer is only flushed when it is full, or by FlushLevel, Flush and
+	// rotations if it is NOT positive.
+	FlushInterval time.Duration
+	// Records at or above FlushLevel flush the buffer immediately. The zero
+	// value, Trace, is treated as Error since flushing every record defeats
+	// the buffer. Use Off to never flush by levels.
+	FlushLevel iface.Level
+	// SyncPolicy specifies when log files are synced by fsync. The default is
+	// SyncNever, which leaves it to the OS.
+	SyncPolicy SyncPolicy
+	// SyncInterval is the interval of SyncPeriodically.
+	SyncInterval time.Duration
+
+	// ErrorHandler handles the errors of the background goroutines, including
+	// the errors of updating the Symlink.
+	// The errors are ignored if it is nil.
+	ErrorHandler func(err error)
 }
 
 func (this *Config) SetDefaults() {
@@ -22,4 +88,25 @@ func (this *Config) SetDefaults() {
 	} else if this.MaxFileSize < 0 {
 		this.MaxFileSize = math.MaxInt64
 	}
+
+	if this.Pattern == "" {
+		this.Pattern = "{{date}}/{{time}}.log"
+	}
+	if this.App == "" {
+		this.App = filepath.Base(os.Args[0])
+	}
+	if this.Period == 0 {
+		this.Period = Daily
+	}
+	if this.Location == nil {
+		this.Location = time.Local
+	}
+
+	if this.FlushLevel == iface.Trace {
+		this.FlushLevel = iface.Error
+	}
+
+	if this.ErrorHandler == nil {
+		this.ErrorHandler = func(error) {}
+	}
 }
@@ -0,0 +1,216 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	gos "github.com/gratonos/goutil/os"
+)
+
+const (
+	Hourly = time.Hour
+	Daily  = 24 * time.Hour
+	Weekly = 7 * Daily
+)
+
+const (
+	dateLayout = "20060102"
+	timeLayout = "150405.000000"
+)
+
+type nameSegment struct {
+	element string // empty if it is a literal
+	literal string
+}
+
+// A namer generates the paths of log files, which are relative to Dir and
+// slash-separated, with a pattern.
+type namer struct {
+	segments []nameSegment
+	app      string
+	pid      string
+	hasSeq   bool
+	unique   bool // whether the paths of different files are different
+	fileRe   *regexp.Regexp
+	dirRes   []*regexp.Regexp
+}
+
+func newNamer(pattern, app string) (*namer, error) {
+	if pattern == "" || path.IsAbs(pattern) || path.Clean(pattern) != pattern ||
+		strings.HasPrefix(pattern, "../") || strings.HasSuffix(pattern, "/") {
+		return nil, fmt.Errorf("invalid pattern %q", pattern)
+	}
+
+	this := &namer{app: app, pid: strconv.Itoa(os.Getpid())}
+	var exprs []string
+	for rest := pattern; rest != ""; {
+		begin := strings.Index(rest, "{{")
+		if begin < 0 {
+			this.addLiteral(rest, &exprs)
+			break
+		}
+		end := strings.Index(rest[begin:], "}}")
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed element in pattern %q", pattern)
+		}
+		this.addLiteral(rest[:begin], &exprs)
+		element := rest[begin+2 : begin+end]
+		var expr string
+		switch element {
+		case "app":
+			expr = regexp.QuoteMeta(app)
+		case "pid":
+			expr = `\d+`
+		case "date":
+			expr = `\d{8}`
+		case "time":
+			expr = `\d{6}\.\d{6}`
+			this.unique = true
+		case "seq":
+			expr = `\d+`
+			this.unique = true
+			this.hasSeq = true
+		default:
+			return nil, fmt.Errorf("unknown element {{%s}} in pattern %q", element, pattern)
+		}
+		this.segments = append(this.segments, nameSegment{element: element})
+		exprs = append(exprs, expr)
+		rest = rest[begin+end+2:]
+	}
+
+	expr := strings.Join(exprs, "")
+	this.fileRe = regexp.MustCompile("^" + expr + "$")
+	// the directories are matched from the outermost one
+	for i := strings.Index(expr, "/"); i >= 0; {
+		this.dirRes = append(this.dirRes, regexp.MustCompile("^"+expr[:i]+"$"))
+		next := strings.Index(expr[i+1:], "/")
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return this, nil
+}
+
+func (this *namer) addLiteral(literal string, exprs *[]string) {
+	if literal != "" {
+		this.segments = append(this.segments, nameSegment{literal: literal})
+		*exprs = append(*exprs, regexp.QuoteMeta(literal))
+	}
+}
+
+// Name returns the path of the log file of the time and the sequence number.
+func (this *namer) Name(t time.Time, seq int) string {
+	var buf []byte
+	for _, segment := range this.segments {
+		switch segment.element {
+		case "":
+			buf = append(buf, segment.literal...)
+		case "app":
+			buf = append(buf, this.app...)
+		case "pid":
+			buf = append(buf, this.pid...)
+		case "date":
+			buf = t.AppendFormat(buf, dateLayout)
+		case "time":
+			buf = t.AppendFormat(buf, timeLayout)
+		case "seq":
+			buf = strconv.AppendInt(buf, int64(seq), 10)
+		}
+	}
+	return string(buf)
+}
+
+// MatchFile reports whether the relative path is a log file.
+func (this *namer) MatchFile(rel string) bool {
+	return this.fileRe.MatchString(rel)
+}
+
+// MatchAny reports whether the relative path is a log file, which may be
+// compressed.
+func (this *namer) MatchAny(rel string) bool {
+	if this.fileRe.MatchString(rel) {
+		return true
+	}
+	ext := path.Ext(rel)
+	return ext != "" && ext != tmpExtension && this.MatchCompressed(rel[:len(rel)-len(ext)])
+}
+
+// MatchCompressed reports whether the relative path without the extension is
+// a compressed log file, i.e. a log file with an optional suffix of ".<n>",
+// see compressFile.
+func (this *namer) MatchCompressed(rel string) bool {
+	if this.fileRe.MatchString(rel) {
+		return true
+	}
+	dot := strings.LastIndexByte(rel, '.')
+	if dot < 0 || dot == len(rel)-1 {
+		return false
+	}
+	for _, c := range rel[dot+1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return this.fileRe.MatchString(rel[:dot])
+}
+
+// MatchDir reports whether the relative path is a directory of log files.
+func (this *namer) MatchDir(rel string) bool {
+	for _, re := range this.dirRes {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// periodRange returns the rotation period that t is in.
+func periodRange(t time.Time, period time.Duration) (start, end time.Time) {
+	year, month, day := t.Date()
+	midnight := time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	switch {
+	case period == Weekly:
+		// weeks begin on Monday
+		start = midnight.AddDate(0, 0, -(int(midnight.Weekday())+6)%7)
+		return start, start.AddDate(0, 0, 7)
+	case period%Daily == 0:
+		days := int(period / Daily)
+		civilDays := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / 86400
+		start = midnight.AddDate(0, 0, -int(civilDays%int64(days)))
+		return start, start.AddDate(0, 0, days)
+	default:
+		// the periods within a day begin at midnight
+		start = midnight.Add(t.Sub(midnight).Truncate(period))
+		end = start.Add(period)
+		if next := midnight.AddDate(0, 0, 1); end.After(next) {
+			end = next
+		}
+		return start, end
+	}
+}
+
+// updateSymlink points the symlink to the file atomically.
+func updateSymlink(link, file string) error {
+	target, err := filepath.Rel(filepath.Dir(link), file)
+	if err != nil {
+		return err
+	}
+	if current, err := os.Readlink(link); err == nil && current == target {
+		return nil
+	}
+	tmp := link + tmpExtension
+	if err := gos.RemoveIfExists(tmp); err != nil {
+		return err
+	}
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, link)
+}
@@ -0,0 +1,195 @@
+package file
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	gos "github.com/gratonos/goutil/os"
+)
+
+type retention struct {
+	maxAge       time.Duration
+	maxFiles     int
+	maxTotalSize int64
+}
+
+func (this retention) enabled() bool {
+	return this.maxAge > 0 || this.maxFiles > 0 || this.maxTotalSize > 0
+}
+
+type logFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Cleanup enforces the retention of log files in Dir immediately. The file
+// being written is never removed.
+func (this *Writer) Cleanup() error {
+	this.lock.Lock()
+	dir, current, policy := this.dir, this.path, this.retention
+	this.lock.Unlock()
+
+	this.cleanLock.Lock()
+	defer this.cleanLock.Unlock()
+
+	if err := this.cleanup(dir, current, policy, time.Now()); err != nil {
+		return fmt.Errorf("writer/file.Cleanup: %v", err)
+	}
+	return nil
+}
+
+// triggerCleanup MUST be called with the lock held. It never blocks.
+func (this *Writer) triggerCleanup() {
+	if !this.retention.enabled() && this.compressor == nil && this.symlink == "" {
+		return
+	}
+	this.cleanPending = true
+	if !this.cleaning {
+		this.cleaning = true
+		this.janitors.Add(1)
+		go this.janitor()
+	}
+}
+
+// janitor updates the symlink, compresses rotated log files and enforces the
+// retention until no cleanup is pending.
+func (this *Writer) janitor() {
+	defer this.janitors.Done()
+
+	for {
+		this.lock.Lock()
+		if !this.cleanPending {
+			this.cleaning = false
+			this.lock.Unlock()
+			return
+		}
+		this.cleanPending = false
+		dir, current, policy, compressor := this.dir, this.path, this.retention, this.compressor
+		this.lock.Unlock()
+
+		var err error
+		this.cleanLock.Lock()
+		if this.symlink != "" && current != "" {
+			err = updateSymlink(filepath.Join(dir, this.symlink), current)
+		}
+		if err == nil && compressor != nil {
+			err = this.compressAll(dir, current, compressor)
+		}
+		if err == nil {
+			err = this.cleanup(dir, current, policy, time.Now())
+		}
+		this.cleanLock.Unlock()
+		if err != nil {
+			this.errorHandler(fmt.Errorf("writer/file.janitor: %v", err))
+		}
+	}
+}
+
+// cleanup removes the log files in dir beyond the retention. The current is
+// the file being written when the cleanup is triggered, see removeRotated.
+func (this *Writer) cleanup(dir, current string, policy retention, now time.Time) error {
+	if !policy.enabled() {
+		return nil
+	}
+
+	files, dirs, err := listLogFiles(dir, this.namer)
+	if err != nil {
+		return err
+	}
+
+	count := len(files)
+	var total int64
+	for _, file := range files {
+		total += file.size
+	}
+	for _, file := range files {
+		expired := policy.maxAge > 0 && !file.modTime.After(now.Add(-policy.maxAge))
+		if !expired &&
+			(policy.maxFiles <= 0 || count <= policy.maxFiles) &&
+			(policy.maxTotalSize <= 0 || total <= policy.maxTotalSize) {
+			break
+		}
+		if file.path == current {
+			continue
+		}
+		removed, err := this.removeRotated(file.path, gos.RemoveIfExists)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			continue
+		}
+		count--
+		total -= file.size
+	}
+
+	// remove the empty directories from the innermost ones
+	currentDir := filepath.Dir(current)
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if dirs[i] != currentDir {
+			// fails unless it is empty
+			_, _ = this.removeRotated(dirs[i], os.Remove)
+		}
+	}
+	return nil
+}
+
+// removeRotated removes the file or the directory at path unless the file
+// being written is or is in it. The lock is held while removing, so that a
+// file opened by a rotation after the directory walk is never removed.
+func (this *Writer) removeRotated(path string, remove func(path string) error) (bool, error) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if this.path != "" && (path == this.path || path == filepath.Dir(this.path)) {
+		return false, nil
+	}
+	return true, remove(path)
+}
+
+// listLogFiles returns the log files in dir, which may be compressed, from
+// the oldest to the newest, and the directories of log files in dir.
+func listLogFiles(dir string, namer *namer) (files []logFile, dirs []string, err error) {
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if entry.IsDir() {
+			if !namer.MatchDir(rel) {
+				return filepath.SkipDir
+			}
+			dirs = append(dirs, path)
+			return nil
+		}
+		if !entry.Type().IsRegular() || !namer.MatchAny(rel) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		files = append(files, logFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	sort.SliceStable(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	return files, dirs, err
+}
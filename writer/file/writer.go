@@ -2,9 +2,11 @@
 package file
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"sync"
@@ -17,42 +19,113 @@ import (
 
 const (
 	checkInterval = time.Second * 5
-	dateFormat    = "%04d%02d%02d"
-	timeFormat    = "%02d%02d%02d.%06d"
-	extension     = ".log"
 	dirPerm       = 0770
+	filePerm      = 0666
 )
 
 type Writer struct {
-	dir         string
-	maxFileSize int64
+	dir          string
+	maxFileSize  int64
+	namer        *namer
+	period       time.Duration
+	location     *time.Location
+	symlink      string
+	retention    retention
+	compressor   Compressor
+	errorHandler func(err error)
 
-	writer    io.WriteCloser
+	bufferSize    int
+	flushInterval time.Duration
+	flushLevel    iface.Level
+	syncPolicy    SyncPolicy
+	syncInterval  time.Duration
+
+	file      *os.File
+	buf       *bufio.Writer // nil if writes are NOT buffered
+	writer    io.Writer     // file or buf
 	path      string
 	checkTime time.Time
-	yearDay   int
+	periodEnd time.Time
+	seq       int
 	fileSize  int64
 	lock      sync.Mutex
+
+	cleaning     bool
+	cleanPending bool
+	cleanLock    sync.Mutex
+	janitors     sync.WaitGroup
+
+	flusherDone chan struct{}
+	flushers    sync.WaitGroup
 }
 
 func Open(config Config) (*Writer, error) {
 	config.SetDefaults()
 
+	namer, err := newNamer(config.Pattern, config.App)
+	if err != nil {
+		return nil, fmt.Errorf("writer/file.Open: %v", err)
+	}
+	if !namer.unique && config.MaxFileSize != math.MaxInt64 {
+		return nil, errors.New("writer/file.Open: the pattern has neither {{time}} " +
+			"nor {{seq}}, thus the MaxFileSize must be negative")
+	}
+	if config.Period < 0 {
+		return nil, errors.New("writer/file.Open: the period must NOT be negative")
+	}
+	if config.SyncPolicy == SyncPeriodically && config.SyncInterval <= 0 {
+		return nil, errors.New("writer/file.Open: the sync interval must be positive")
+	}
 	if err := checkDir(config.Dir); err != nil {
 		return nil, fmt.Errorf("writer/file.Open: %v", err)
 	}
 
-	return &Writer{
+	writer := &Writer{
 		dir:         config.Dir,
 		maxFileSize: config.MaxFileSize,
-	}, nil
+		namer:       namer,
+		period:      config.Period,
+		location:    config.Location,
+		symlink:     config.Symlink,
+		retention: retention{
+			maxAge:       config.MaxAge,
+			maxFiles:     config.MaxFiles,
+			maxTotalSize: config.MaxTotalSize,
+		},
+		compressor:   config.Compressor,
+		errorHandler: config.ErrorHandler,
+
+		bufferSize:    config.BufferSize,
+		flushInterval: config.FlushInterval,
+		flushLevel:    config.FlushLevel,
+		syncPolicy:    config.SyncPolicy,
+		syncInterval:  config.SyncInterval,
+	}
+	if writer.compressor != nil {
+		// compress the files left by the previous process
+		writer.lock.Lock()
+		writer.triggerCleanup()
+		writer.lock.Unlock()
+	}
+	return writer, nil
 }
 
+// Close flushes and closes the current file, and then waits for the
+// background goroutines.
 func (this *Writer) Close() error {
 	this.lock.Lock()
-	defer this.lock.Unlock()
+	err := this.closeFile()
+	done := this.flusherDone
+	this.flusherDone = nil
+	this.lock.Unlock()
 
-	if err := this.closeFile(); err != nil {
+	if done != nil {
+		close(done)
+	}
+	this.flushers.Wait()
+	this.janitors.Wait()
+
+	if err != nil {
 		return fmt.Errorf("writer/file.Close: %v", err)
 	}
 	return nil
@@ -67,6 +140,13 @@ func (this *Writer) Write(bs []byte, record *iface.Record) error {
 		n, err = this.writer.Write(bs)
 		this.fileSize += int64(n)
 	}
+	if err == nil {
+		if this.syncPolicy == SyncAlways {
+			err = this.flush(true)
+		} else if this.buf != nil && record.Level >= this.flushLevel {
+			err = this.flush(false)
+		}
+	}
 
 	this.lock.Unlock()
 
@@ -97,6 +177,7 @@ func (this *Writer) SetDir(dir string) error {
 		return fmt.Errorf("writer/file.SetDir: %v", err)
 	}
 	this.dir = dir
+	this.seq = 0
 	return nil
 }
 
@@ -114,13 +195,17 @@ func (this *Writer) SetMaxFileSize(size int64) error {
 	if size <= 0 {
 		return errors.New("writer/file.SetMaxFileSize: size must be positive")
 	}
+	if !this.namer.unique && size != math.MaxInt64 {
+		return errors.New("writer/file.SetMaxFileSize: the pattern has neither {{time}} " +
+			"nor {{seq}}, thus the size must be math.MaxInt64")
+	}
 	this.maxFileSize = size
 	return nil
 }
 
 func (this *Writer) checkFile(record *iface.Record) error {
 	if this.writer == nil ||
-		this.yearDay != record.Time.YearDay() ||
+		!record.Time.Before(this.periodEnd) ||
 		this.fileSize >= this.maxFileSize {
 		return this.createFile(record)
 	} else if time.Since(this.checkTime) >= checkInterval {
@@ -141,35 +226,77 @@ func (this *Writer) createFile(record *iface.Record) error {
 		return err
 	}
 
-	date := fmt.Sprintf(dateFormat, record.Time.Year(), record.Time.Month(), record.Time.Day())
-	dir := filepath.Join(this.dir, date)
-	if err := os.MkdirAll(dir, dirPerm); err != nil {
-		return err
+	t := record.Time.In(this.location)
+	_, periodEnd := periodRange(t, this.period)
+	if !periodEnd.Equal(this.periodEnd) {
+		this.seq = 0
 	}
 
-	clock := fmt.Sprintf(timeFormat, record.Time.Hour(), record.Time.Minute(),
-		record.Time.Second(), record.Time.Nanosecond()/1000)
-	filename := clock + extension
-	path := filepath.Join(dir, filename)
-	file, err := os.Create(path)
-	if err != nil {
-		return err
+	for {
+		path := filepath.Join(this.dir, filepath.FromSlash(this.namer.Name(t, this.seq)))
+		if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
+			return err
+		}
+		// a file of the same name is appended unless the sequence number is
+		// used, e.g. after a restart
+		flag := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		if this.namer.hasSeq {
+			flag |= os.O_EXCL
+			this.seq++
+			if this.compressor != nil {
+				if ok, err := gos.FileExists(path + this.compressor.Extension()); err != nil {
+					return err
+				} else if ok {
+					continue
+				}
+			}
+		}
+		file, err := os.OpenFile(path, flag, filePerm)
+		if err != nil {
+			if this.namer.hasSeq && os.IsExist(err) {
+				continue
+			}
+			return err
+		}
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return err
+		}
+
+		this.file = file
+		this.writer = file
+		if this.bufferSize > 0 {
+			if this.buf == nil {
+				this.buf = bufio.NewWriterSize(file, this.bufferSize)
+			} else {
+				this.buf.Reset(file)
+			}
+			this.writer = this.buf
+		}
+		this.path = path
+		this.periodEnd = periodEnd
+		this.fileSize = info.Size()
+		break
 	}
 
-	this.writer = file
-	this.path = path
-	this.yearDay = record.Time.YearDay()
-	this.fileSize = 0
+	this.startFlusher()
+	this.triggerCleanup()
 
 	return nil
 }
 
 func (this *Writer) closeFile() error {
-	if this.writer != nil {
-		if err := this.writer.Close(); err != nil {
-			return err
+	if this.file != nil {
+		err := this.flush(this.syncPolicy != SyncNever)
+		if closeErr := this.file.Close(); err == nil {
+			err = closeErr
 		}
+		this.file = nil
 		this.writer = nil
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
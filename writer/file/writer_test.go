@@ -0,0 +1,452 @@
+package file_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gratonos/gxlog/iface"
+	"github.com/gratonos/gxlog/writer/file"
+)
+
+func TestRetention(t *testing.T) {
+	dir := t.TempDir()
+	expired := filepath.Join(dir, "20000101", "000000.000000.log")
+	mustWrite(t, expired, "expired\n")
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(expired, old, old); err != nil {
+		t.Fatalf("TestRetention: %v", err)
+	}
+	mustWrite(t, filepath.Join(dir, "notes", "keep.log"), "not a date directory\n")
+
+	writer, err := file.Open(file.Config{
+		Dir:         dir,
+		MaxFileSize: 1,
+		MaxAge:      24 * time.Hour,
+		MaxFiles:    3,
+	})
+	if err != nil {
+		t.Fatalf("TestRetention: %v", err)
+	}
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		record := &iface.Record{Time: now.Add(time.Duration(i) * time.Millisecond)}
+		if err := writer.Write([]byte("log\n"), record); err != nil {
+			t.Fatalf("TestRetention: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("TestRetention: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "20000101")); !os.IsNotExist(err) {
+		t.Errorf("TestRetention: the expired date directory should be removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes", "keep.log")); err != nil {
+		t.Errorf("TestRetention: unrelated files should be kept: %v", err)
+	}
+	files := listFiles(t, filepath.Join(dir, now.Format("20060102")))
+	if len(files) != 3 {
+		t.Fatalf("TestRetention: want 3 files, got %v", files)
+	}
+	last := now.Add(9*time.Millisecond).Format("150405.000000") + ".log"
+	if files[2] != last {
+		t.Errorf("TestRetention: the newest file %s should be kept, got %v", last, files)
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := file.Open(file.Config{Dir: dir, MaxTotalSize: 10})
+	if err != nil {
+		t.Fatalf("TestCleanup: %v", err)
+	}
+	defer writer.Close()
+
+	dateDir := filepath.Join(dir, time.Now().Format("20060102"))
+	for _, name := range []string{"000001.000000.log", "000002.000000.log", "000003.000000.log"} {
+		mustWrite(t, filepath.Join(dateDir, name), "12345\n")
+	}
+	if err := writer.Cleanup(); err != nil {
+		t.Fatalf("TestCleanup: %v", err)
+	}
+	files := listFiles(t, dateDir)
+	if len(files) != 1 || files[0] != "000003.000000.log" {
+		t.Errorf("TestCleanup: unexpected files: %v", files)
+	}
+}
+
+func TestNaming(t *testing.T) {
+	dir := t.TempDir()
+	config := file.Config{
+		Dir:      dir,
+		Pattern:  "logs/{{app}}-{{date}}-{{seq}}.log",
+		App:      "app",
+		Period:   file.Hourly,
+		Location: time.UTC,
+		Symlink:  "app.log",
+	}
+	writer, err := file.Open(config)
+	if err != nil {
+		t.Fatalf("TestNaming: %v", err)
+	}
+	base := time.Date(2026, 10, 18, 10, 15, 0, 0, time.UTC)
+	write := func(writer *file.Writer, offset time.Duration) {
+		record := &iface.Record{Time: base.Add(offset).In(time.Local)}
+		if err := writer.Write([]byte(offset.String()+"\n"), record); err != nil {
+			t.Fatalf("TestNaming: %v", err)
+		}
+	}
+	write(writer, 0)
+	write(writer, 44*time.Minute)
+	write(writer, 45*time.Minute) // 11:00, a new period
+	if err := writer.Close(); err != nil {
+		t.Fatalf("TestNaming: %v", err)
+	}
+
+	// a restart in the same day MUST NOT overwrite the existing files
+	writer, err = file.Open(config)
+	if err != nil {
+		t.Fatalf("TestNaming: %v", err)
+	}
+	write(writer, 75*time.Minute)
+	if err := writer.Close(); err != nil {
+		t.Fatalf("TestNaming: %v", err)
+	}
+
+	logDir := filepath.Join(dir, "logs")
+	want := map[string]string{
+		"app-20261018-0.log": "0s\n44m0s\n",
+		"app-20261018-1.log": "45m0s\n",
+		"app-20261018-2.log": "1h15m0s\n",
+	}
+	files := listFiles(t, logDir)
+	if len(files) != len(want) {
+		t.Fatalf("TestNaming: unexpected files: %v", files)
+	}
+	for name, content := range want {
+		bs, err := os.ReadFile(filepath.Join(logDir, name))
+		if err != nil || string(bs) != content {
+			t.Errorf("TestNaming: %s: want %q, got %q, %v", name, content, bs, err)
+		}
+	}
+	target, err := os.Readlink(filepath.Join(dir, "app.log"))
+	if err != nil || target != filepath.Join("logs", "app-20261018-2.log") {
+		t.Errorf("TestNaming: unexpected symlink: %q, %v", target, err)
+	}
+
+	if _, err := file.Open(file.Config{Dir: dir, Pattern: "{{app}}.log"}); err == nil {
+		t.Errorf("TestNaming: a pattern without {{time}} or {{seq}} should be rejected")
+	}
+	single, err := file.Open(file.Config{Dir: dir, Pattern: "{{app}}.log", MaxFileSize: -1})
+	if err != nil {
+		t.Fatalf("TestNaming: %v", err)
+	}
+	if err := single.SetMaxFileSize(1024); err == nil {
+		t.Errorf("TestNaming: a max file size of a pattern without {{time}} or {{seq}} should be rejected")
+	}
+	if err := single.Close(); err != nil {
+		t.Fatalf("TestNaming: %v", err)
+	}
+	if _, err := file.Open(file.Config{Dir: dir, Pattern: "{{app}}-{{host}}-{{seq}}.log"}); err == nil {
+		t.Errorf("TestNaming: an unknown element should be rejected")
+	}
+}
+
+func mustWrite(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0770); err != nil {
+		t.Fatalf("mustWrite: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0660); err != nil {
+		t.Fatalf("mustWrite: %v", err)
+	}
+}
+
+func listFiles(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("listFiles: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCompression(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := file.Open(file.Config{
+		Dir:         dir,
+		MaxFileSize: 1,
+		Compressor:  file.Gzip(gzip.BestSpeed),
+	})
+	if err != nil {
+		t.Fatalf("TestCompression: %v", err)
+	}
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		record := &iface.Record{Time: now.Add(time.Duration(i) * time.Millisecond)}
+		if err := writer.Write([]byte("log\n"), record); err != nil {
+			t.Fatalf("TestCompression: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("TestCompression: %v", err)
+	}
+
+	dateDir := filepath.Join(dir, now.Format("20060102"))
+	files := listFiles(t, dateDir)
+	if len(files) != 3 || !strings.HasSuffix(files[0], ".log.gz") ||
+		!strings.HasSuffix(files[1], ".log.gz") || !strings.HasSuffix(files[2], ".log") {
+		t.Fatalf("TestCompression: unexpected files: %v", files)
+	}
+	if content := readGzip(t, filepath.Join(dateDir, files[0])); content != "log\n" {
+		t.Errorf("TestCompression: unexpected content: %q", content)
+	}
+}
+
+func TestCompressionRecovery(t *testing.T) {
+	dir := t.TempDir()
+	dateDir := filepath.Join(dir, "20200101")
+	mustWrite(t, filepath.Join(dateDir, "000001.000000.log.gz.tmp"), "partial")
+	mustWrite(t, filepath.Join(dateDir, "000002.000000.log"), "renamed\n")
+	mustWrite(t, filepath.Join(dateDir, "000002.000000.log.gz"), gzipString(t, "renamed\n"))
+	mustWrite(t, filepath.Join(dateDir, "000003.000000.log"), "rotated\n")
+
+	writer, err := file.Open(file.Config{Dir: dir, Compressor: file.Gzip(gzip.BestSpeed)})
+	if err != nil {
+		t.Fatalf("TestCompressionRecovery: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("TestCompressionRecovery: %v", err)
+	}
+
+	// the log file left after the rename is compressed again
+	files := listFiles(t, dateDir)
+	if len(files) != 3 || files[0] != "000002.000000.log.1.gz" ||
+		files[1] != "000002.000000.log.gz" || files[2] != "000003.000000.log.gz" {
+		t.Fatalf("TestCompressionRecovery: unexpected files: %v", files)
+	}
+	if content := readGzip(t, filepath.Join(dateDir, files[0])); content != "renamed\n" {
+		t.Errorf("TestCompressionRecovery: unexpected content: %q", content)
+	}
+	if content := readGzip(t, filepath.Join(dateDir, files[2])); content != "rotated\n" {
+		t.Errorf("TestCompressionRecovery: unexpected content: %q", content)
+	}
+}
+
+func TestCompressionAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	config := file.Config{
+		Dir:         dir,
+		MaxFileSize: -1,
+		Pattern:     "{{date}}.log",
+		Compressor:  file.Gzip(gzip.BestSpeed),
+	}
+	today := time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local)
+	tomorrow := today.Add(24 * time.Hour)
+	for _, msg := range []string{"first process\n", "second process\n"} {
+		// the file of the previous process is compressed before the restart
+		writer, err := file.Open(config)
+		if err != nil {
+			t.Fatalf("TestCompressionAfterRestart: %v", err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("TestCompressionAfterRestart: %v", err)
+		}
+		if writer, err = file.Open(config); err != nil {
+			t.Fatalf("TestCompressionAfterRestart: %v", err)
+		}
+		if err := writer.Write([]byte(msg), &iface.Record{Time: today}); err != nil {
+			t.Fatalf("TestCompressionAfterRestart: %v", err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("TestCompressionAfterRestart: %v", err)
+		}
+	}
+	// rotate after the restart on the same day
+	writer, err := file.Open(config)
+	if err != nil {
+		t.Fatalf("TestCompressionAfterRestart: %v", err)
+	}
+	if err := writer.Write([]byte("third process\n"), &iface.Record{Time: tomorrow}); err != nil {
+		t.Fatalf("TestCompressionAfterRestart: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("TestCompressionAfterRestart: %v", err)
+	}
+
+	var content string
+	for _, name := range listFiles(t, dir) {
+		if strings.HasPrefix(name, "20261018.log") {
+			content += readGzip(t, filepath.Join(dir, name))
+		}
+	}
+	for _, msg := range []string{"first process\n", "second process\n"} {
+		if !strings.Contains(content, msg) {
+			t.Errorf("TestCompressionAfterRestart: %q is lost: %v", msg, listFiles(t, dir))
+		}
+	}
+}
+
+type blockingCompressor struct {
+	file.Compressor
+	entered chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (this *blockingCompressor) Compress(dst io.Writer, src io.Reader) error {
+	this.once.Do(func() {
+		close(this.entered)
+		<-this.release
+	})
+	return this.Compressor.Compress(dst, src)
+}
+
+func TestCompressionDuringRotation(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	dateDir := filepath.Join(dir, now.Format("20060102"))
+	// the directory is walked after the old file is compressed
+	mustWrite(t, filepath.Join(dir, "20000101", "000000.000000.log"), "old\n")
+	if err := os.MkdirAll(dateDir, 0770); err != nil {
+		t.Fatalf("TestCompressionDuringRotation: %v", err)
+	}
+	compressor := &blockingCompressor{
+		Compressor: file.Gzip(gzip.BestSpeed),
+		entered:    make(chan struct{}),
+		release:    make(chan struct{}),
+	}
+
+	writer, err := file.Open(file.Config{Dir: dir, Compressor: compressor})
+	if err != nil {
+		t.Fatalf("TestCompressionDuringRotation: %v", err)
+	}
+	// the janitor is compressing the old file when the first file is opened
+	<-compressor.entered
+	if err := writer.Write([]byte("log1\n"), &iface.Record{Time: now}); err != nil {
+		t.Fatalf("TestCompressionDuringRotation: %v", err)
+	}
+	close(compressor.release)
+	if err := writer.Write([]byte("log2\n"), &iface.Record{Time: now}); err != nil {
+		t.Fatalf("TestCompressionDuringRotation: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("TestCompressionDuringRotation: %v", err)
+	}
+
+	files := listFiles(t, dateDir)
+	if len(files) != 1 || !strings.HasSuffix(files[0], ".log") {
+		t.Fatalf("TestCompressionDuringRotation: unexpected files: %v", files)
+	}
+	content, err := os.ReadFile(filepath.Join(dateDir, files[0]))
+	if err != nil {
+		t.Fatalf("TestCompressionDuringRotation: %v", err)
+	}
+	if string(content) != "log1\nlog2\n" {
+		t.Errorf("TestCompressionDuringRotation: unexpected content: %q", content)
+	}
+}
+
+func gzipString(t *testing.T, content string) string {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(content)); err != nil {
+		t.Fatalf("gzipString: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("gzipString: %v", err)
+	}
+	return buf.String()
+}
+
+func readGzip(t *testing.T, path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("readGzip: %v", err)
+	}
+	defer f.Close()
+	reader, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("readGzip: %v", err)
+	}
+	bs, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("readGzip: %v", err)
+	}
+	return string(bs)
+}
+
+func TestBuffer(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := file.Open(file.Config{Dir: dir, BufferSize: 1024})
+	if err != nil {
+		t.Fatalf("TestBuffer: %v", err)
+	}
+	defer writer.Close()
+
+	now := time.Now()
+	path := filepath.Join(dir, now.Format("20060102"), now.Format("150405.000000")+".log")
+	write := func(level iface.Level, content string) {
+		if err := writer.Write([]byte(content), &iface.Record{Time: now, Level: level}); err != nil {
+			t.Fatalf("TestBuffer: %v", err)
+		}
+	}
+	check := func(want string) {
+		bs, err := os.ReadFile(path)
+		if err != nil || string(bs) != want {
+			t.Fatalf("TestBuffer: want %q, got %q, %v", want, bs, err)
+		}
+	}
+
+	write(iface.Info, "info\n")
+	check("")
+	write(iface.Error, "error\n")
+	check("info\nerror\n")
+	write(iface.Warn, "warn\n")
+	check("info\nerror\n")
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("TestBuffer: %v", err)
+	}
+	check("info\nerror\nwarn\n")
+}
+
+func TestFlushInterval(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := file.Open(file.Config{
+		Dir:           dir,
+		BufferSize:    1024,
+		FlushInterval: 10 * time.Millisecond,
+		FlushLevel:    iface.Off,
+		SyncPolicy:    file.SyncPeriodically,
+		SyncInterval:  10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("TestFlushInterval: %v", err)
+	}
+	defer writer.Close()
+
+	now := time.Now()
+	if err := writer.Write([]byte("fatal\n"), &iface.Record{Time: now, Level: iface.Fatal}); err != nil {
+		t.Fatalf("TestFlushInterval: %v", err)
+	}
+	path := filepath.Join(dir, now.Format("20060102"), now.Format("150405.000000")+".log")
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		if bs, _ := os.ReadFile(path); string(bs) == "fatal\n" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("TestFlushInterval: the buffer is NOT flushed")
+		}
+	}
+}
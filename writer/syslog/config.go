@@ -1,8 +1,10 @@
 package syslog
 
 import (
+	"crypto/tls"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/gratonos/gxlog/iface"
 )
@@ -37,16 +39,77 @@ const (
 	SevDebug
 )
 
+// A Format is the format of syslog messages.
+type Format int
+
+const (
+	FormatBSD     Format = iota // RFC 3164
+	FormatRFC5424               // RFC 5424
+)
+
 type Config struct {
+	// Tag is the TAG of RFC 3164 or the APP-NAME of RFC 5424.
 	Tag         string
 	Facility    Facility
 	SeverityMap map[iface.Level]Severity
+
+	// Network is "udp", "tcp", "tcp+tls" or any network of net.Dial, e.g.
+	// "unixgram". The local syslog is used if it is empty. The messages are
+	// framed by octet counting of RFC 6587 for "tcp" and "tcp+tls".
+	Network string
+	// Addr is the address of the remote syslog, e.g. "localhost:514".
+	Addr string
+	// TLSConfig is used for "tcp+tls". The default config is used if it is nil.
+	TLSConfig *tls.Config
+	// Timeout is the timeout of dialing and each write if it is positive.
+	Timeout time.Duration
+
+	Format Format
+	// Hostname is the HOSTNAME of a remote syslog. The default is os.Hostname.
+	Hostname string
+	// MsgID is the MSGID of RFC 5424. It is "-" if empty.
+	MsgID string
+	// SDID is the SD-ID of the STRUCTURED-DATA of RFC 5424, which is built
+	// from Record.Contexts, e.g. "ctx@32473". There is no STRUCTURED-DATA if
+	// it is empty.
+	SDID string
+
+	// MinBackoff and MaxBackoff are the bounds of the exponential backoff with
+	// jitter between reconnections. The defaults are 100ms and 30s.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// SpillSize is the max total bytes of the messages buffered while the
+	// syslog is disconnected, which are replayed in order after reconnection.
+	// The default is 1MB. Nothing is buffered if it is negative.
+	SpillSize int64
+	// SpillPath is the path of a file to buffer the messages instead of the
+	// memory if it is NOT empty. The messages in it are replayed by the next
+	// Writer opened with it, e.g. after a restart.
+	SpillPath string
 }
 
 func (this *Config) SetDefaults() {
 	if this.Tag == "" {
 		this.Tag = filepath.Base(os.Args[0])
 	}
+	if this.Hostname == "" && (this.Network != "" || this.Format == FormatRFC5424) {
+		this.Hostname, _ = os.Hostname()
+	}
+
+	if this.MinBackoff <= 0 {
+		this.MinBackoff = 100 * time.Millisecond
+	}
+	if this.MaxBackoff <= 0 {
+		this.MaxBackoff = 30 * time.Second
+	}
+	if this.MaxBackoff < this.MinBackoff {
+		this.MaxBackoff = this.MinBackoff
+	}
+	if this.SpillSize == 0 {
+		this.SpillSize = 1024 * 1024
+	} else if this.SpillSize < 0 {
+		this.SpillSize = 0
+	}
 
 	severityMap := map[iface.Level]Severity{
 		iface.Trace: SevDebug,
@@ -0,0 +1,136 @@
+package syslog
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gratonos/gxlog/iface"
+)
+
+const (
+	rfc5424TimeLayout = "2006-01-02T15:04:05.000000Z07:00"
+	nilValue          = '-'
+)
+
+// The max lengths of the header fields of RFC 5424.
+const (
+	maxHostname = 255
+	maxAppName  = 48
+	maxProcID   = 128
+	maxMsgID    = 32
+	maxName     = 32 // SD-ID and PARAM-NAME
+)
+
+var procID = strconv.Itoa(os.Getpid())
+
+// appendBSDHeader appends the header of RFC 3164. The hostname is omitted
+// when logging to the local syslog.
+func appendBSDHeader(buf []byte, priority int, timestamp time.Time, hostname, tag string) []byte {
+	buf = appendPriority(buf, priority)
+	buf = timestamp.AppendFormat(buf, time.Stamp)
+	buf = append(buf, ' ')
+	if hostname != "" {
+		buf = append(buf, hostname...)
+		buf = append(buf, ' ')
+	}
+	buf = append(buf, tag...)
+	buf = append(buf, '[')
+	buf = append(buf, procID...)
+	buf = append(buf, "]: "...)
+	return buf
+}
+
+// appendRFC5424Header appends the HEADER and the STRUCTURED-DATA of RFC 5424
+// and a space. The STRUCTURED-DATA is built from the contexts of the record
+// if sdID is NOT empty.
+func appendRFC5424Header(buf []byte, priority int, record *iface.Record,
+	hostname, appName, msgID, sdID string) []byte {
+	buf = appendPriority(buf, priority)
+	buf = append(buf, "1 "...)
+	buf = record.Time.AppendFormat(buf, rfc5424TimeLayout)
+	buf = append(buf, ' ')
+	buf = appendHeaderField(buf, hostname, maxHostname)
+	buf = append(buf, ' ')
+	buf = appendHeaderField(buf, appName, maxAppName)
+	buf = append(buf, ' ')
+	buf = appendHeaderField(buf, procID, maxProcID)
+	buf = append(buf, ' ')
+	buf = appendHeaderField(buf, msgID, maxMsgID)
+	buf = append(buf, ' ')
+	buf = appendStructuredData(buf, sdID, record.Contexts)
+	buf = append(buf, ' ')
+	return buf
+}
+
+func appendPriority(buf []byte, priority int) []byte {
+	buf = append(buf, '<')
+	buf = strconv.AppendInt(buf, int64(priority), 10)
+	return append(buf, '>')
+}
+
+// appendHeaderField appends a field of PRINTUSASCII, where the invalid
+// characters are replaced with '_'.
+func appendHeaderField(buf []byte, field string, maxLen int) []byte {
+	if field == "" {
+		return append(buf, nilValue)
+	}
+	if len(field) > maxLen {
+		field = field[:maxLen]
+	}
+	for i := 0; i < len(field); i++ {
+		if c := field[i]; c >= 33 && c <= 126 {
+			buf = append(buf, c)
+		} else {
+			buf = append(buf, '_')
+		}
+	}
+	return buf
+}
+
+func appendStructuredData(buf []byte, sdID string, contexts []iface.Context) []byte {
+	if sdID == "" || len(contexts) == 0 {
+		return append(buf, nilValue)
+	}
+	buf = append(buf, '[')
+	buf = appendName(buf, sdID, maxName)
+	for _, context := range contexts {
+		buf = append(buf, ' ')
+		buf = appendName(buf, context.Key, maxName)
+		buf = append(buf, `="`...)
+		buf = appendParamValue(buf, context.Value.String())
+		buf = append(buf, '"')
+	}
+	return append(buf, ']')
+}
+
+// appendName appends an SD-ID or a PARAM-NAME, where the invalid characters
+// are replaced with '_'.
+func appendName(buf []byte, name string, maxLen int) []byte {
+	if name == "" {
+		return append(buf, '_')
+	}
+	if len(name) > maxLen {
+		name = name[:maxLen]
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c < 33 || c > 126 || c == '=' || c == ']' || c == '"' {
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+func appendParamValue(buf []byte, value string) []byte {
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '"', '\\', ']':
+			buf = append(buf, '\\', c)
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
@@ -0,0 +1,241 @@
+package syslog
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+)
+
+// A spill buffers messages in order while the syslog is disconnected.
+type spill interface {
+	// Push returns false if the spill is full.
+	Push(msg []byte) (bool, error)
+	// Front returns the oldest message, which is valid until Pop is called.
+	Front() ([]byte, error)
+	Pop()
+	Len() int
+	Close() error
+}
+
+type memSpill struct {
+	msgs    [][]byte
+	head    int
+	size    int64
+	maxSize int64
+}
+
+func newMemSpill(maxSize int64) *memSpill {
+	return &memSpill{maxSize: maxSize}
+}
+
+func (this *memSpill) Push(msg []byte) (bool, error) {
+	if this.size+int64(len(msg)) > this.maxSize {
+		return false, nil
+	}
+	this.msgs = append(this.msgs, append([]byte(nil), msg...))
+	this.size += int64(len(msg))
+	return true, nil
+}
+
+func (this *memSpill) Front() ([]byte, error) {
+	return this.msgs[this.head], nil
+}
+
+func (this *memSpill) Pop() {
+	this.size -= int64(len(this.msgs[this.head]))
+	this.msgs[this.head] = nil
+	this.head++
+	if this.head == len(this.msgs) {
+		this.msgs = this.msgs[:0]
+		this.head = 0
+	} else if this.head > len(this.msgs)/2 {
+		// compact to bound the slice if the spill is never emptied
+		n := copy(this.msgs, this.msgs[this.head:])
+		for i := n; i < len(this.msgs); i++ {
+			this.msgs[i] = nil
+		}
+		this.msgs = this.msgs[:n]
+		this.head = 0
+	}
+}
+
+func (this *memSpill) Len() int {
+	return len(this.msgs) - this.head
+}
+
+func (this *memSpill) Close() error {
+	return nil
+}
+
+const (
+	headerSize = 8 // the offset of the oldest message
+	lengthSize = 4
+)
+
+// A diskSpill stores messages in a file, which is replayed after a restart.
+// The file consists of the header and the messages, each of which is prefixed
+// with its length. The popped messages are discarded by compaction once they
+// take more space than maxSize, thus the file is bounded even if the spill is
+// never emptied.
+type diskSpill struct {
+	path     string
+	file     *os.File
+	readOff  int64
+	writeOff int64
+	count    int
+	size     int64
+	maxSize  int64
+	front    []byte
+}
+
+func openDiskSpill(path string, maxSize int64) (*diskSpill, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0660)
+	if err != nil {
+		return nil, err
+	}
+	this := &diskSpill{path: path, file: file, maxSize: maxSize}
+	if err := this.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return this, nil
+}
+
+// load loads the messages left by the previous process and discards a
+// partially written one.
+func (this *diskSpill) load() error {
+	var header [headerSize]byte
+	if _, err := this.file.ReadAt(header[:], 0); err != nil {
+		if err != io.EOF {
+			return err
+		}
+		return this.reset()
+	}
+	info, err := this.file.Stat()
+	if err != nil {
+		return err
+	}
+	this.readOff = int64(binary.BigEndian.Uint64(header[:]))
+	this.writeOff = this.readOff
+	var length [lengthSize]byte
+	for {
+		if _, err := this.file.ReadAt(length[:], this.writeOff); err != nil {
+			break
+		}
+		n := int64(binary.BigEndian.Uint32(length[:]))
+		end := this.writeOff + lengthSize + n
+		if end > info.Size() {
+			break
+		}
+		this.writeOff = end
+		this.count++
+		this.size += n
+	}
+	if this.count == 0 {
+		return this.reset()
+	}
+	return this.file.Truncate(this.writeOff)
+}
+
+func (this *diskSpill) Push(msg []byte) (bool, error) {
+	if this.size+int64(len(msg)) > this.maxSize {
+		return false, nil
+	}
+	record := make([]byte, lengthSize, lengthSize+len(msg))
+	binary.BigEndian.PutUint32(record, uint32(len(msg)))
+	record = append(record, msg...)
+	if _, err := this.file.WriteAt(record, this.writeOff); err != nil {
+		return false, err
+	}
+	this.writeOff += int64(len(record))
+	this.count++
+	this.size += int64(len(msg))
+	return true, nil
+}
+
+func (this *diskSpill) Front() ([]byte, error) {
+	if this.front != nil {
+		return this.front, nil
+	}
+	var length [lengthSize]byte
+	if _, err := this.file.ReadAt(length[:], this.readOff); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, binary.BigEndian.Uint32(length[:]))
+	if _, err := this.file.ReadAt(msg, this.readOff+lengthSize); err != nil {
+		return nil, err
+	}
+	this.front = msg
+	return msg, nil
+}
+
+// Pop MUST be called after Front. A failure to update the header only leads
+// to duplicate messages after a restart, thus it is ignored.
+func (this *diskSpill) Pop() {
+	this.readOff += lengthSize + int64(len(this.front))
+	this.count--
+	this.size -= int64(len(this.front))
+	this.front = nil
+	if this.count == 0 {
+		_ = this.reset()
+		return
+	}
+	if this.readOff-headerSize > this.maxSize {
+		if err := this.compact(); err == nil {
+			return
+		}
+	}
+	var header [headerSize]byte
+	binary.BigEndian.PutUint64(header[:], uint64(this.readOff))
+	_, _ = this.file.WriteAt(header[:], 0)
+}
+
+func (this *diskSpill) Len() int {
+	return this.count
+}
+
+func (this *diskSpill) Close() error {
+	return this.file.Close()
+}
+
+// compact moves the messages to a new file, which is then renamed to the
+// spill file, thus a crash leaves either the old messages or the new ones.
+func (this *diskSpill) compact() error {
+	tmp := this.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0660)
+	if err != nil {
+		return err
+	}
+	var header [headerSize]byte
+	binary.BigEndian.PutUint64(header[:], headerSize)
+	_, err = file.Write(header[:])
+	if err == nil {
+		_, err = io.Copy(file, io.NewSectionReader(this.file, this.readOff, this.writeOff-this.readOff))
+	}
+	if err == nil {
+		err = os.Rename(tmp, this.path)
+	}
+	if err != nil {
+		file.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	this.file.Close()
+	this.file = file
+	this.writeOff -= this.readOff - headerSize
+	this.readOff = headerSize
+	return nil
+}
+
+func (this *diskSpill) reset() error {
+	this.readOff, this.writeOff = headerSize, headerSize
+	this.count, this.size = 0, 0
+	if err := this.file.Truncate(0); err != nil {
+		return err
+	}
+	var header [headerSize]byte
+	binary.BigEndian.PutUint64(header[:], headerSize)
+	_, err := this.file.WriteAt(header[:], 0)
+	return err
+}
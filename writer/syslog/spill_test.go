@@ -0,0 +1,74 @@
+package syslog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMemSpillCompaction(t *testing.T) {
+	spill := newMemSpill(64)
+
+	steadyTraffic(t, spill, 1000)
+
+	if limit := 8; cap(spill.msgs) > limit {
+		t.Errorf("TestMemSpillCompaction: the capacity %d exceeds %d", cap(spill.msgs), limit)
+	}
+	if spill.Len() != 1 {
+		t.Errorf("TestMemSpillCompaction: unexpected length: %d", spill.Len())
+	}
+}
+
+func TestDiskSpillCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill")
+	spill, err := openDiskSpill(path, 64)
+	if err != nil {
+		t.Fatalf("TestDiskSpillCompaction: %v", err)
+	}
+	defer spill.Close()
+
+	steadyTraffic(t, spill, 1000)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("TestDiskSpillCompaction: %v", err)
+	}
+	if limit := int64(headerSize + 2*(64+lengthSize+7)); info.Size() > limit {
+		t.Errorf("TestDiskSpillCompaction: the file size %d exceeds %d", info.Size(), limit)
+	}
+
+	// the messages left are replayed after a restart
+	spill.Close()
+	if spill, err = openDiskSpill(path, 64); err != nil {
+		t.Fatalf("TestDiskSpillCompaction: %v", err)
+	}
+	msg, err := spill.Front()
+	if spill.Len() != 1 || err != nil || string(msg) != "msg1000" {
+		t.Errorf("TestDiskSpillCompaction: unexpected spill: %d, %q, %v", spill.Len(), msg, err)
+	}
+}
+
+// steadyTraffic pushes a message before each pop, thus the spill is never
+// emptied.
+func steadyTraffic(t *testing.T, spill spill, count int) {
+	next := 0
+	push := func() {
+		if ok, err := spill.Push([]byte(fmt.Sprintf("msg%04d", next))); !ok || err != nil {
+			t.Fatalf("steadyTraffic: push: %v, %v", ok, err)
+		}
+		next++
+	}
+	push()
+	for i := 0; i < count; i++ {
+		push()
+		msg, err := spill.Front()
+		if err != nil {
+			t.Fatalf("steadyTraffic: %v", err)
+		}
+		if expect := fmt.Sprintf("msg%04d", i); string(msg) != expect {
+			t.Fatalf("steadyTraffic: msg: %q, expect: %q", msg, expect)
+		}
+		spill.Pop()
+	}
+}
@@ -1,67 +1,248 @@
 package syslog
 
 import (
+	"crypto/tls"
 	"errors"
-	"fmt"
+	"math/rand"
 	"net"
-	"os"
+	"strconv"
+	"sync"
 	"time"
 )
 
+// A syslog writes messages to the connection, or buffers them in the spill
+// while it is reconnecting in background.
 type syslog struct {
-	conn net.Conn
+	network    string
+	addr       string
+	tlsConfig  *tls.Config
+	timeout    time.Duration
+	framing    bool // octet counting of RFC 6587
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	conn         net.Conn // nil if it is reconnecting
+	frame        []byte
+	spill        spill
+	reconnecting bool
+	closed       bool
+	dropped      uint64
+	reconnects   uint64
+	lock         sync.Mutex
+
+	done chan struct{}
+	wg   sync.WaitGroup
 }
 
-func syslogDial() (*syslog, error) {
-	log := new(syslog)
-	if err := log.connect(); err != nil {
+func syslogDial(config *Config) (*syslog, error) {
+	log := &syslog{
+		network:    config.Network,
+		addr:       config.Addr,
+		tlsConfig:  config.TLSConfig,
+		timeout:    config.Timeout,
+		framing:    config.Network == "tcp" || config.Network == "tcp+tls",
+		minBackoff: config.MinBackoff,
+		maxBackoff: config.MaxBackoff,
+		done:       make(chan struct{}),
+	}
+
+	if config.SpillPath != "" {
+		spill, err := openDiskSpill(config.SpillPath, config.SpillSize)
+		if err != nil {
+			return nil, err
+		}
+		log.spill = spill
+	} else {
+		log.spill = newMemSpill(config.SpillSize)
+	}
+
+	conn, err := log.dial()
+	if err != nil {
+		log.spill.Close()
 		return nil, err
 	}
+	if log.spill.Len() > 0 {
+		// replay the messages left by the previous process
+		log.reconnect(conn)
+	} else {
+		log.conn = conn
+	}
 	return log, nil
 }
 
-func (this *syslog) Write(timestamp time.Time, priority int, tag string, msg []byte) error {
+// Write buffers the message in the spill if the syslog is disconnected, and
+// returns an error if the message is dropped since the spill is full.
+func (this *syslog) Write(msg []byte) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
 	if this.conn != nil {
-		if err := this.write(timestamp, priority, tag, msg); err == nil {
+		err := write(this.conn, &this.frame, msg, this.framing, this.timeout)
+		if err == nil {
 			return nil
 		}
-		this.Close()
-	}
-	if err := this.connect(); err != nil {
-		return err
+		this.conn.Close()
+		this.conn = nil
+		this.reconnect(nil)
 	}
-	if err := this.write(timestamp, priority, tag, msg); err != nil {
-		this.Close()
+
+	ok, err := this.spill.Push(msg)
+	if !ok {
+		this.dropped++
+		if err == nil {
+			err = errors.New("the spill is full, the message is dropped")
+		}
 		return err
 	}
 	return nil
 }
 
+func (this *syslog) Stats() Stats {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	return Stats{
+		Dropped:    this.dropped,
+		Buffered:   this.spill.Len(),
+		Reconnects: this.reconnects,
+	}
+}
+
+// Close closes the connection and the spill. The messages in a disk spill
+// are kept for the next process.
 func (this *syslog) Close() error {
+	this.lock.Lock()
+	if this.closed {
+		this.lock.Unlock()
+		return nil
+	}
+	this.closed = true
+	var err error
 	if this.conn != nil {
-		err := this.conn.Close()
+		err = this.conn.Close()
 		this.conn = nil
-		return err
 	}
-	return nil
+	this.lock.Unlock()
+
+	close(this.done)
+	this.wg.Wait()
+
+	if spillErr := this.spill.Close(); err == nil {
+		err = spillErr
+	}
+	return err
+}
+
+// reconnect MUST be called with the lock held. It starts the reconnector
+// unless it is running. The conn may be nil.
+func (this *syslog) reconnect(conn net.Conn) {
+	if this.reconnecting || this.closed {
+		return
+	}
+	this.reconnecting = true
+	this.wg.Add(1)
+	go this.reconnector(conn)
 }
 
-func (this *syslog) connect() error {
+// reconnector reconnects with exponential backoff, and then replays the
+// messages in the spill in order before the connection is used by Write.
+func (this *syslog) reconnector(conn net.Conn) {
+	defer this.wg.Done()
+
+	var frame []byte
+	backoff := this.minBackoff
+	for {
+		if conn == nil {
+			// equal jitter
+			delay := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+			select {
+			case <-this.done:
+				return
+			case <-time.After(delay):
+			}
+			if backoff *= 2; backoff > this.maxBackoff {
+				backoff = this.maxBackoff
+			}
+			var err error
+			if conn, err = this.dial(); err != nil {
+				continue
+			}
+			this.lock.Lock()
+			this.reconnects++
+			this.lock.Unlock()
+		}
+
+		for conn != nil {
+			this.lock.Lock()
+			if this.closed {
+				this.lock.Unlock()
+				conn.Close()
+				return
+			}
+			if this.spill.Len() == 0 {
+				this.conn = conn
+				this.reconnecting = false
+				this.lock.Unlock()
+				return
+			}
+			msg, err := this.spill.Front()
+			this.lock.Unlock()
+
+			// the front is NOT changed by Write, thus it is written without the lock
+			if err == nil {
+				err = write(conn, &frame, msg, this.framing, this.timeout)
+			}
+			if err != nil {
+				conn.Close()
+				conn = nil
+				break
+			}
+
+			this.lock.Lock()
+			this.spill.Pop()
+			this.lock.Unlock()
+		}
+	}
+}
+
+func (this *syslog) dial() (net.Conn, error) {
+	if this.network == "" {
+		return dialLocal()
+	}
+
+	dialer := &net.Dialer{Timeout: this.timeout}
+	if this.network == "tcp+tls" {
+		return tls.DialWithDialer(dialer, "tcp", this.addr, this.tlsConfig)
+	}
+	return dialer.Dial(this.network, this.addr)
+}
+
+func dialLocal() (net.Conn, error) {
 	networks := []string{"unixgram", "unix"}
 	paths := []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
 	for _, network := range networks {
 		for _, path := range paths {
 			if conn, err := net.Dial(network, path); err == nil {
-				this.conn = conn
-				return nil
+				return conn, nil
 			}
 		}
 	}
-	return errors.New("unix syslog delivery error")
+	return nil, errors.New("unix syslog delivery error")
 }
 
-func (this *syslog) write(timestamp time.Time, priority int, tag string, msg []byte) error {
-	_, err := fmt.Fprintf(this.conn, "<%d>%s %s[%d]: %s",
-		priority, timestamp.Format(time.Stamp), tag, os.Getpid(), msg)
+func write(conn net.Conn, frame *[]byte, msg []byte, framing bool, timeout time.Duration) error {
+	if timeout > 0 {
+		if err := conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+			return err
+		}
+	}
+	if framing {
+		// MSG-LEN SP SYSLOG-MSG
+		*frame = strconv.AppendInt((*frame)[:0], int64(len(msg)), 10)
+		*frame = append(*frame, ' ')
+		*frame = append(*frame, msg...)
+		msg = *frame
+	}
+	_, err := conn.Write(msg)
 	return err
 }
@@ -2,25 +2,44 @@
 package syslog
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 
 	"github.com/gratonos/gxlog/iface"
 )
 
+// Stats are the statistics of a Writer.
+type Stats struct {
+	Dropped    uint64 // the number of messages dropped since the spill is full
+	Buffered   int    // the number of messages in the spill
+	Reconnects uint64 // the number of successful reconnections
+}
+
+// A Writer writes messages to the syslog. If the connection is broken, it
+// reconnects in background, and the messages are buffered in a spill in the
+// meantime, which never blocks Write.
 type Writer struct {
 	tag      string
 	facility Facility
+	format   Format
+	hostname string
+	msgID    string
+	sdID     string
 
 	severities [iface.LogLevelCount]Severity
 	log        *syslog
+	buf        []byte
 	lock       sync.Mutex
 }
 
 func Open(config Config) (*Writer, error) {
 	config.SetDefaults()
 
-	log, err := syslogDial()
+	if config.Network == "" && config.Addr != "" {
+		return nil, errors.New("writer/syslog.Open: the network is missing")
+	}
+	log, err := syslogDial(&config)
 	if err != nil {
 		return nil, fmt.Errorf("writer/syslog.Open: %v", err)
 	}
@@ -28,6 +47,10 @@ func Open(config Config) (*Writer, error) {
 	writer := &Writer{
 		tag:      config.Tag,
 		facility: config.Facility,
+		format:   config.Format,
+		hostname: config.Hostname,
+		msgID:    config.MsgID,
+		sdID:     config.SDID,
 		log:      log,
 	}
 	writer.MapSeverities(config.SeverityMap)
@@ -49,7 +72,18 @@ func (this *Writer) Write(bs []byte, record *iface.Record) error {
 
 	severity := this.severities[record.Level]
 	priority := int(this.facility) | int(severity)
-	err := this.log.Write(record.Time, priority, this.tag, bs)
+	if this.format == FormatRFC5424 {
+		this.buf = appendRFC5424Header(this.buf[:0], priority, record,
+			this.hostname, this.tag, this.msgID, this.sdID)
+	} else {
+		hostname := this.hostname
+		if this.log.network == "" {
+			hostname = "" // local
+		}
+		this.buf = appendBSDHeader(this.buf[:0], priority, record.Time, hostname, this.tag)
+	}
+	this.buf = append(this.buf, bs...)
+	err := this.log.Write(this.buf)
 
 	this.lock.Unlock()
 
@@ -59,6 +93,10 @@ func (this *Writer) Write(bs []byte, record *iface.Record) error {
 	return nil
 }
 
+func (this *Writer) Stats() Stats {
+	return this.log.Stats()
+}
+
 func (this *Writer) Tag() string {
 	this.lock.Lock()
 	defer this.lock.Unlock()
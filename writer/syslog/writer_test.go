@@ -0,0 +1,356 @@
+package syslog_test
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gratonos/gxlog/iface"
+	"github.com/gratonos/gxlog/writer/syslog"
+)
+
+var testTime = time.Date(2026, 10, 18, 10, 15, 0, 123456000, time.UTC)
+
+func TestRFC5424(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("TestRFC5424: %v", err)
+	}
+	defer conn.Close()
+
+	writer, err := syslog.Open(syslog.Config{
+		Tag:      "app",
+		Facility: syslog.FacUser,
+		Network:  "udp",
+		Addr:     conn.LocalAddr().String(),
+		Format:   syslog.FormatRFC5424,
+		Hostname: "my host",
+		SDID:     "ctx@32473",
+	})
+	if err != nil {
+		t.Fatalf("TestRFC5424: %v", err)
+	}
+	defer writer.Close()
+
+	record := &iface.Record{
+		Time:     testTime,
+		Level:    iface.Info,
+		Contexts: []iface.Context{iface.String("k", `a"]\`), iface.Int("n", 1)},
+	}
+	if err := writer.Write([]byte("msg\n"), record); err != nil {
+		t.Fatalf("TestRFC5424: %v", err)
+	}
+	record.Contexts = nil
+	if err := writer.Write([]byte("no contexts\n"), record); err != nil {
+		t.Fatalf("TestRFC5424: %v", err)
+	}
+
+	want := []string{
+		fmt.Sprintf(`<14>1 2026-10-18T10:15:00.123456Z my_host app %d - `+
+			`[ctx@32473 k="a\"\]\\" n="1"] msg`+"\n", os.Getpid()),
+		fmt.Sprintf("<14>1 2026-10-18T10:15:00.123456Z my_host app %d - - no contexts\n",
+			os.Getpid()),
+	}
+	buf := make([]byte, 2048)
+	for _, msg := range want {
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("TestRFC5424: %v", err)
+		}
+		if string(buf[:n]) != msg {
+			t.Errorf("TestRFC5424: want %q, got %q", msg, buf[:n])
+		}
+	}
+}
+
+func TestTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("TestTCP: %v", err)
+	}
+	defer listener.Close()
+	testStream(t, listener, syslog.Config{Network: "tcp"})
+}
+
+func TestTLS(t *testing.T) {
+	cert, pool := selfSignedCert(t)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0",
+		&tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatalf("TestTLS: %v", err)
+	}
+	defer listener.Close()
+	testStream(t, listener, syslog.Config{
+		Network:   "tcp+tls",
+		TLSConfig: &tls.Config{RootCAs: pool},
+	})
+}
+
+// testStream checks the octet counting framing with the BSD format.
+func testStream(t *testing.T, listener net.Listener, config syslog.Config) {
+	frames := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for i := 0; i < cap(frames); i++ {
+			frame, err := readFrame(reader)
+			if err != nil {
+				return
+			}
+			frames <- frame
+		}
+	}()
+
+	config.Tag = "app"
+	config.Facility = syslog.FacDaemon
+	config.Addr = listener.Addr().String()
+	config.Hostname = "host"
+	config.Timeout = time.Second
+	writer, err := syslog.Open(config)
+	if err != nil {
+		t.Fatalf("testStream: %v", err)
+	}
+	defer writer.Close()
+
+	record := &iface.Record{Time: testTime, Level: iface.Error}
+	msgs := []string{"first\n", "second line\nthird line\n"}
+	for _, msg := range msgs {
+		if err := writer.Write([]byte(msg), record); err != nil {
+			t.Fatalf("testStream: %v", err)
+		}
+	}
+	for _, msg := range msgs {
+		want := fmt.Sprintf("<27>Oct 18 10:15:00 host app[%d]: %s", os.Getpid(), msg)
+		select {
+		case frame := <-frames:
+			if frame != want {
+				t.Errorf("testStream: want %q, got %q", want, frame)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("testStream: timeout")
+		}
+	}
+}
+
+func readFrame(reader *bufio.Reader) (string, error) {
+	length, err := reader.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(length[:len(length)-1])
+	if err != nil {
+		return "", err
+	}
+	frame := make([]byte, n)
+	if _, err := io.ReadFull(reader, frame); err != nil {
+		return "", err
+	}
+	return string(frame), nil
+}
+
+func selfSignedCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("selfSignedCert: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("selfSignedCert: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("selfSignedCert: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+func TestReconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("TestReconnect: %v", err)
+	}
+	defer listener.Close()
+	conns := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+		}
+	}()
+
+	writer, err := syslog.Open(syslog.Config{
+		Tag:        "app",
+		Facility:   syslog.FacUser,
+		Network:    "tcp",
+		Addr:       listener.Addr().String(),
+		Hostname:   "host",
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("TestReconnect: %v", err)
+	}
+	defer writer.Close()
+
+	conn := acceptConn(t, conns)
+	write(t, writer, "a\n")
+	expectFrames(t, bufio.NewReader(conn), "a\n")
+	resetConn(t, conn)
+
+	for _, msg := range []string{"b\n", "c\n", "d\n"} {
+		write(t, writer, msg)
+	}
+	conn = acceptConn(t, conns)
+	defer conn.Close()
+	expectFrames(t, bufio.NewReader(conn), "b\n", "c\n", "d\n")
+	if stats := writer.Stats(); stats.Reconnects != 1 || stats.Dropped != 0 || stats.Buffered != 0 {
+		t.Errorf("TestReconnect: unexpected stats: %+v", stats)
+	}
+}
+
+func TestSpill(t *testing.T) {
+	spillPath := filepath.Join(t.TempDir(), "spill")
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("TestSpill: %v", err)
+	}
+	addr := listener.Addr().String()
+	conns := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			conns <- conn
+		}
+	}()
+
+	config := syslog.Config{
+		Tag:        "app",
+		Facility:   syslog.FacUser,
+		Network:    "tcp",
+		Addr:       addr,
+		Hostname:   "host",
+		MinBackoff: time.Millisecond,
+		MaxBackoff: 10 * time.Millisecond,
+		SpillSize:  int64(len(frameOf("x\n")) * 2),
+		SpillPath:  spillPath,
+	}
+	writer, err := syslog.Open(config)
+	if err != nil {
+		t.Fatalf("TestSpill: %v", err)
+	}
+	listener.Close()
+	resetConn(t, acceptConn(t, conns))
+
+	write(t, writer, "x\n")
+	write(t, writer, "y\n")
+	record := &iface.Record{Time: testTime, Level: iface.Info}
+	if err := writer.Write([]byte("z\n"), record); err == nil {
+		t.Errorf("TestSpill: the message should be dropped")
+	}
+	if stats := writer.Stats(); stats.Buffered != 2 || stats.Dropped != 1 {
+		t.Errorf("TestSpill: unexpected stats: %+v", stats)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("TestSpill: %v", err)
+	}
+
+	// the messages in the spill file are replayed by the next writer
+	listener, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("TestSpill: the address is NOT available: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			conns <- conn
+		}
+	}()
+	writer, err = syslog.Open(config)
+	if err != nil {
+		t.Fatalf("TestSpill: %v", err)
+	}
+	defer writer.Close()
+	// the spill is still full until it is replayed
+	for deadline := time.Now().Add(time.Second); writer.Stats().Buffered > 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("TestSpill: the spill is NOT replayed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	write(t, writer, "w\n")
+	conn := acceptConn(t, conns)
+	defer conn.Close()
+	expectFrames(t, bufio.NewReader(conn), "x\n", "y\n", "w\n")
+}
+
+func write(t *testing.T, writer *syslog.Writer, msg string) {
+	record := &iface.Record{Time: testTime, Level: iface.Info}
+	if err := writer.Write([]byte(msg), record); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+func frameOf(msg string) string {
+	return fmt.Sprintf("<14>Oct 18 10:15:00 host app[%d]: %s", os.Getpid(), msg)
+}
+
+func expectFrames(t *testing.T, reader *bufio.Reader, msgs ...string) {
+	for _, msg := range msgs {
+		frame, err := readFrame(reader)
+		if err != nil {
+			t.Fatalf("expectFrames: %v", err)
+		}
+		if want := frameOf(msg); frame != want {
+			t.Errorf("expectFrames: want %q, got %q", want, frame)
+		}
+	}
+}
+
+func acceptConn(t *testing.T, conns <-chan net.Conn) net.Conn {
+	select {
+	case conn := <-conns:
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		return conn
+	case <-time.After(time.Second):
+		t.Fatalf("acceptConn: timeout")
+		return nil
+	}
+}
+
+// resetConn resets the connection to make the writes of the peer fail.
+func resetConn(t *testing.T, conn net.Conn) {
+	if err := conn.(*net.TCPConn).SetLinger(0); err != nil {
+		t.Fatalf("resetConn: %v", err)
+	}
+	conn.Close()
+	time.Sleep(50 * time.Millisecond)
+}
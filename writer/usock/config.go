@@ -0,0 +1,53 @@
+package usock
+
+import (
+	"time"
+)
+
+// A SlowPolicy specifies how to handle a client whose queue is full.
+type SlowPolicy int
+
+const (
+	// DropSlow drops the messages for the client, and a gap marker with the
+	// number of dropped messages is sent before the next message.
+	DropSlow SlowPolicy = iota
+	// DisconnectSlow disconnects the client.
+	DisconnectSlow
+)
+
+type Config struct {
+	// Path is the path of the unix domain socket.
+	Path string
+	// MaxClients is the max number of connected clients. Further connections
+	// are closed immediately. The default is 16.
+	MaxClients int
+	// QueueSize is the max number of messages queued for each client, which
+	// are sent by a goroutine of the client. The default is 1024.
+	QueueSize int
+	// SlowPolicy specifies how to handle a client whose queue is full. The
+	// default is DropSlow.
+	SlowPolicy SlowPolicy
+	// WriteTimeout is the deadline of each write to a client, which is
+	// disconnected on a timeout. The default is 5s.
+	WriteTimeout time.Duration
+	// Frame frames the notices sent to the clients, e.g. the gap markers, if
+	// it is NOT nil, which MUST be the same as the framing of the logs, e.g.
+	// the Frame of formatter/frame.Formatter, to keep the streams of frames
+	// intact.
+	Frame func(notice []byte) []byte
+	// ReplaySize is the number of the latest logs kept in memory for the
+	// replay of Subscription. There is no replay if it is NOT positive.
+	ReplaySize int
+}
+
+func (this *Config) SetDefaults() {
+	if this.MaxClients <= 0 {
+		this.MaxClients = 16
+	}
+	if this.QueueSize <= 0 {
+		this.QueueSize = 1024
+	}
+	if this.WriteTimeout <= 0 {
+		this.WriteTimeout = 5 * time.Second
+	}
+}
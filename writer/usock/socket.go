@@ -1,64 +1,140 @@
 package usock
 
 import (
+	"bufio"
 	"net"
+	"strconv"
 	"sync"
+	"time"
+
+	"github.com/gratonos/gxlog/iface"
 )
 
+const maxSubscriptionSize = 64 * 1024
+
+type message struct {
+	bs      []byte // shared by the clients, which is read-only
+	dropped int    // the number of messages dropped right before it
+}
+
+type client struct {
+	conn      net.Conn
+	queue     chan message
+	filter    *filter // matchAll before the first subscription
+	dropped   int
+	replayEnd uint64 // the logs since the client connects are NOT replayed
+}
+
 type socket struct {
-	listener net.Listener
-	conns    map[int64]net.Conn
-	id       int64
-	wg       sync.WaitGroup
-	lock     sync.Mutex
+	listener     net.Listener
+	maxClients   int
+	queueSize    int
+	slowPolicy   SlowPolicy
+	writeTimeout time.Duration
+	frame        func(notice []byte) []byte // nil if the notices are NOT framed
+
+	clients map[int64]*client
+	id      int64
+	ring    *ring // nil if the replay is disabled
+	lock    sync.Mutex
+
+	serving sync.WaitGroup
+	workers sync.WaitGroup
 }
 
-func openSocket(path string) (*socket, error) {
-	listener, err := net.Listen("unix", path)
+func openSocket(config *Config) (*socket, error) {
+	listener, err := net.Listen("unix", config.Path)
 	if err != nil {
 		return nil, err
 	}
 
 	sock := &socket{
-		listener: listener,
-		conns:    make(map[int64]net.Conn),
+		listener:     listener,
+		maxClients:   config.MaxClients,
+		queueSize:    config.QueueSize,
+		slowPolicy:   config.SlowPolicy,
+		writeTimeout: config.WriteTimeout,
+		frame:        config.Frame,
+		clients:      make(map[int64]*client),
+	}
+	if config.ReplaySize > 0 {
+		sock.ring = newRing(config.ReplaySize)
 	}
-	sock.wg.Add(1)
+	sock.serving.Add(1)
 
 	go sock.serve()
 
 	return sock, nil
 }
 
+// Close stops accepting clients, and then waits for the clients to send the
+// queued messages.
 func (this *socket) Close() error {
 	if err := this.listener.Close(); err != nil {
 		return err
 	}
 
-	this.wg.Wait()
+	this.serving.Wait()
 
 	this.lock.Lock()
-	defer this.lock.Unlock()
-
-	for id, conn := range this.conns {
-		conn.Close()
-		delete(this.conns, id)
+	for id, c := range this.clients {
+		delete(this.clients, id)
+		close(c.queue)
 	}
+	this.lock.Unlock()
+
+	this.workers.Wait()
 
 	return nil
 }
 
-func (this *socket) Write(bs []byte) {
+func (this *socket) Clients() int {
 	this.lock.Lock()
+	defer this.lock.Unlock()
 
-	for id, conn := range this.conns {
-		if _, err := conn.Write(bs); err != nil {
-			conn.Close()
-			delete(this.conns, id)
+	return len(this.clients)
+}
+
+// Write queues the message for every subscribed client without blocking.
+func (this *socket) Write(bs []byte, record *iface.Record) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if len(this.clients) == 0 && this.ring == nil {
+		return
+	}
+	msg := append([]byte(nil), bs...)
+	if this.ring != nil {
+		this.ring.Push(msg, record)
+	}
+	for id, c := range this.clients {
+		if c.filter.Match(record) {
+			this.push(id, c, msg)
 		}
 	}
+}
 
-	this.lock.Unlock()
+// push MUST be called with the lock held.
+func (this *socket) push(id int64, c *client, msg []byte) {
+	select {
+	case c.queue <- message{bs: msg, dropped: c.dropped}:
+		c.dropped = 0
+	default:
+		if this.slowPolicy == DisconnectSlow {
+			this.remove(id, c)
+			c.conn.Close()
+		} else {
+			c.dropped++
+		}
+	}
+}
+
+// remove MUST be called with the lock held.
+func (this *socket) remove(id int64, c *client) {
+	if this.clients[id] == c {
+		delete(this.clients, id)
+		close(c.queue)
+	}
 }
 
 func (this *socket) serve() {
@@ -70,12 +146,124 @@ func (this *socket) serve() {
 
 		this.lock.Lock()
 
+		if len(this.clients) >= this.maxClients {
+			this.lock.Unlock()
+			conn.Close()
+			continue
+		}
 		id := this.id
 		this.id++
-		this.conns[id] = conn
+		queueSize := this.queueSize
+		if this.ring != nil {
+			queueSize += len(this.ring.entries)
+		}
+		c := &client{conn: conn, queue: make(chan message, queueSize), filter: matchAll}
+		if this.ring != nil {
+			c.replayEnd = this.ring.seq
+		}
+		this.clients[id] = c
+		this.workers.Add(2)
 
 		this.lock.Unlock()
+
+		go this.send(id, c)
+		go this.receive(id, c)
 	}
 
-	this.wg.Done()
+	this.serving.Done()
+}
+
+// send sends the queued messages to the client until the queue is closed or
+// a write fails.
+func (this *socket) send(id int64, c *client) {
+	defer this.workers.Done()
+	defer c.conn.Close()
+
+	var marker []byte
+	for msg := range c.queue {
+		var err error
+		if msg.dropped > 0 {
+			marker = appendGapMarker(marker[:0], msg.dropped)
+			err = this.write(c.conn, this.notice(marker))
+		}
+		if err == nil {
+			err = this.write(c.conn, msg.bs)
+		}
+		if err != nil {
+			this.lock.Lock()
+			this.remove(id, c)
+			this.lock.Unlock()
+			return
+		}
+	}
+}
+
+// receive reads the subscriptions of the client until the end of them, or
+// disconnects the client on an error.
+func (this *socket) receive(id int64, c *client) {
+	defer this.workers.Done()
+
+	scanner := bufio.NewScanner(c.conn)
+	scanner.Buffer(nil, maxSubscriptionSize)
+	for scanner.Scan() {
+		filter, err := parseSubscription(scanner.Bytes())
+		if err != nil {
+			this.lock.Lock()
+			if this.clients[id] == c {
+				this.push(id, c, this.notice([]byte("<gxlog: invalid subscription: "+err.Error()+">\n")))
+			}
+			this.lock.Unlock()
+			continue
+		}
+		this.subscribe(id, c, filter)
+	}
+	if scanner.Err() == nil {
+		// the client may only half-close the connection, e.g.
+		// "nc -U <path> < /dev/null"
+		return
+	}
+
+	this.lock.Lock()
+	this.remove(id, c)
+	this.lock.Unlock()
+	c.conn.Close()
+}
+
+// subscribe replaces the filter of the client, and then replays the latest
+// matching logs.
+func (this *socket) subscribe(id int64, c *client, filter *filter) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	if this.clients[id] != c {
+		return
+	}
+	c.filter = filter
+	if this.ring != nil && filter.replay > 0 {
+		for _, msg := range this.ring.Latest(filter, filter.replay, c.replayEnd) {
+			this.push(id, c, msg)
+		}
+	}
+}
+
+func (this *socket) write(conn net.Conn, bs []byte) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(this.writeTimeout)); err != nil {
+		return err
+	}
+	_, err := conn.Write(bs)
+	return err
+}
+
+// notice frames the notice if the notices are framed.
+func (this *socket) notice(bs []byte) []byte {
+	if this.frame != nil {
+		return this.frame(bs)
+	}
+	return bs
+}
+
+func appendGapMarker(buf []byte, dropped int) []byte {
+	buf = append(buf, "<gxlog: "...)
+	buf = strconv.AppendInt(buf, int64(dropped), 10)
+	return append(buf, " messages dropped>\n"...)
 }
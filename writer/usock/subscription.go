@@ -0,0 +1,179 @@
+package usock
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gratonos/gxlog/iface"
+)
+
+// A Subscription is sent by a client as a line of JSON, e.g.
+//
+//	{"level": "warn", "pkg": "main", "contexts": {"user": "alice"}, "replay": 100}
+//
+// A client is sent only the logs of records that match its subscription. All
+// the fields are optional, and an empty one matches any record.
+//
+// A client is sent all logs until its first subscription, thus a client that
+// sends nothing, e.g. "nc -U <path>", subscribes to all logs and misses none
+// of them after connecting. The subscription is replaced whenever a client
+// sends a new one.
+type Subscription struct {
+	// Level is the min level, e.g. "info".
+	Level string `json:"level,omitempty"`
+	// Pkg is a pattern of path.Match to match Record.Pkg.
+	Pkg string `json:"pkg,omitempty"`
+	// File is a pattern of path.Match to match the base name of Record.File,
+	// or the whole Record.File if the pattern contains a '/'.
+	File string `json:"file,omitempty"`
+	// Contexts are matched by the keys and the string forms of the values
+	// in Record.Contexts, all of which MUST match.
+	Contexts map[string]string `json:"contexts,omitempty"`
+	// Msg is a regular expression to match Record.Msg.
+	Msg string `json:"msg,omitempty"`
+	// Replay is the number of the latest matching logs in the replay ring
+	// sent right after the subscription. Only the logs before the client
+	// connects are replayed, which are NOT sent to it otherwise. See
+	// Config.ReplaySize.
+	Replay int `json:"replay,omitempty"`
+}
+
+type filter struct {
+	level    iface.Level
+	pkg      string
+	file     string
+	contexts map[string]string
+	msg      *regexp.Regexp
+	replay   int
+}
+
+// matchAll is the filter of the clients without a subscription.
+var matchAll = &filter{}
+
+func parseSubscription(line []byte) (*filter, error) {
+	var sub Subscription
+	if err := json.Unmarshal(line, &sub); err != nil {
+		return nil, err
+	}
+	this := &filter{
+		pkg:      sub.Pkg,
+		file:     sub.File,
+		contexts: sub.Contexts,
+		replay:   sub.Replay,
+	}
+	if sub.Level != "" {
+		level, err := iface.ParseLevel(sub.Level)
+		if err != nil {
+			return nil, err
+		}
+		this.level = level
+	}
+	for _, pattern := range []string{sub.Pkg, sub.File} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q", pattern)
+		}
+	}
+	if sub.Msg != "" {
+		re, err := regexp.Compile(sub.Msg)
+		if err != nil {
+			return nil, err
+		}
+		this.msg = re
+	}
+	return this, nil
+}
+
+func (this *filter) Match(record *iface.Record) bool {
+	if record.Level < this.level {
+		return false
+	}
+	if this.pkg != "" {
+		if ok, _ := path.Match(this.pkg, record.Pkg); !ok {
+			return false
+		}
+	}
+	if this.file != "" {
+		file := record.File
+		if !strings.Contains(this.file, "/") {
+			file = path.Base(file)
+		}
+		if ok, _ := path.Match(this.file, file); !ok {
+			return false
+		}
+	}
+	for key, value := range this.contexts {
+		if !hasContext(record.Contexts, key, value) {
+			return false
+		}
+	}
+	return this.msg == nil || this.msg.MatchString(record.Msg)
+}
+
+func hasContext(contexts []iface.Context, key, value string) bool {
+	for _, context := range contexts {
+		if context.Key == key && context.Value.String() == value {
+			return true
+		}
+	}
+	return false
+}
+
+type entry struct {
+	bs     []byte
+	record iface.Record
+	seq    uint64
+}
+
+// A ring keeps the latest logs for replay.
+type ring struct {
+	entries []entry
+	next    int
+	full    bool
+	seq     uint64 // the sequence number of the next log
+}
+
+func newRing(size int) *ring {
+	return &ring{entries: make([]entry, size)}
+}
+
+// Push keeps the log. The bs MUST NOT be modified afterwards.
+func (this *ring) Push(bs []byte, record *iface.Record) {
+	e := &this.entries[this.next]
+	contexts := e.record.Contexts[:0] // owned by the ring
+	e.bs = bs
+	e.record = *record
+	// the contexts may share the underlying array with other records
+	e.record.Contexts = append(contexts, record.Contexts...)
+	e.seq = this.seq
+	this.seq++
+	this.next++
+	if this.next == len(this.entries) {
+		this.next = 0
+		this.full = true
+	}
+}
+
+// Latest returns the latest n logs that match the filter and are kept before
+// the sequence number of end, from the oldest.
+func (this *ring) Latest(filter *filter, n int, end uint64) [][]byte {
+	var logs [][]byte
+	for i := 0; i < len(this.entries) && len(logs) < n; i++ {
+		index := this.next - 1 - i
+		if index < 0 {
+			if !this.full {
+				break
+			}
+			index += len(this.entries)
+		}
+		if e := &this.entries[index]; e.seq < end && filter.Match(&e.record) {
+			logs = append(logs, e.bs)
+		}
+	}
+	for i, j := 0, len(logs)-1; i < j; i, j = i+1, j-1 {
+		logs[i], logs[j] = logs[j], logs[i]
+	}
+	return logs
+}
@@ -1,4 +1,5 @@
-// Package usock implements a unix domain socket writer.
+// Package usock implements a unix domain socket writer, which broadcasts logs
+// to the connected clients, e.g. "nc -U <path>".
 package usock
 
 import (
@@ -12,20 +13,44 @@ import (
 
 const dirPerm = 0770
 
+// A Writer never blocks on the clients. Each client has its own queue, and
+// the messages are sent by a goroutine of the client. A client is able to
+// subscribe to a part of the logs and replay the latest logs, see
+// Subscription.
 type Writer struct {
 	socket *socket
 }
 
+// Open opens a Writer at path with the default config.
 func Open(path string) (*Writer, error) {
-	if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
-		return nil, openError(err)
+	writer, err := open(Config{Path: path})
+	if err != nil {
+		return nil, fmt.Errorf("writer/usock.Open: %v", err)
 	}
-	if err := gos.RemoveIfExists(path); err != nil {
-		return nil, openError(err)
+	return writer, nil
+}
+
+// OpenConfig opens a Writer with the config.
+func OpenConfig(config Config) (*Writer, error) {
+	writer, err := open(config)
+	if err != nil {
+		return nil, fmt.Errorf("writer/usock.OpenConfig: %v", err)
 	}
-	socket, err := openSocket(path)
+	return writer, nil
+}
+
+func open(config Config) (*Writer, error) {
+	config.SetDefaults()
+
+	if err := os.MkdirAll(filepath.Dir(config.Path), dirPerm); err != nil {
+		return nil, err
+	}
+	if err := gos.RemoveIfExists(config.Path); err != nil {
+		return nil, err
+	}
+	socket, err := openSocket(&config)
 	if err != nil {
-		return nil, openError(err)
+		return nil, err
 	}
 	return &Writer{socket: socket}, nil
 }
@@ -37,11 +62,12 @@ func (this *Writer) Close() error {
 	return nil
 }
 
-func (this *Writer) Write(bs []byte, _ *iface.Record) error {
-	this.socket.Write(bs)
-	return nil
+// Clients returns the number of the connected clients.
+func (this *Writer) Clients() int {
+	return this.socket.Clients()
 }
 
-func openError(err error) error {
-	return fmt.Errorf("writer/usock.Open: %v", err)
+func (this *Writer) Write(bs []byte, record *iface.Record) error {
+	this.socket.Write(bs, record)
+	return nil
 }
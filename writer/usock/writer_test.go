@@ -0,0 +1,370 @@
+package usock_test
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gratonos/gxlog/formatter"
+	"github.com/gratonos/gxlog/formatter/frame"
+	"github.com/gratonos/gxlog/iface"
+	"github.com/gratonos/gxlog/writer/usock"
+)
+
+var record = &iface.Record{}
+
+func TestDropSlow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usock")
+	writer, err := usock.OpenConfig(usock.Config{Path: path, QueueSize: 1})
+	if err != nil {
+		t.Fatalf("TestDropSlow: %v", err)
+	}
+	defer writer.Close()
+	reader := connect(t, writer, path)
+
+	// the client does NOT read, thus the writer MUST NOT be blocked
+	msg := []byte(strings.Repeat("x", 64*1024) + "\n")
+	const count = 100
+	for i := 0; i < count; i++ {
+		write(t, writer, msg)
+	}
+
+	lines := make(chan string, 1)
+	go func() {
+		defer close(lines)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			lines <- line
+		}
+	}()
+
+	received, dropped := 0, 0
+	markerRe := regexp.MustCompile(`^<gxlog: (\d+) messages dropped>\n$`)
+	lastWritten, timeout := false, 100*time.Millisecond
+	for done := false; !done; {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatalf("TestDropSlow: the client is disconnected")
+			}
+			if matches := markerRe.FindStringSubmatch(line); matches != nil {
+				n, _ := strconv.Atoi(matches[1])
+				dropped += n
+			} else if line == string(msg) {
+				received++
+			} else if line == "last\n" {
+				done = true
+			} else {
+				t.Fatalf("TestDropSlow: unexpected line: %.32q", line)
+			}
+		case <-time.After(timeout):
+			// the queue is drained, thus the last message will NOT be dropped
+			if lastWritten {
+				t.Fatalf("TestDropSlow: timeout")
+			}
+			write(t, writer, []byte("last\n"))
+			lastWritten, timeout = true, time.Second
+		}
+	}
+	if dropped == 0 || received+dropped != count {
+		t.Errorf("TestDropSlow: received: %d, dropped: %d", received, dropped)
+	}
+}
+
+func TestDisconnectSlow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usock")
+	writer, err := usock.OpenConfig(usock.Config{
+		Path:       path,
+		QueueSize:  1,
+		SlowPolicy: usock.DisconnectSlow,
+	})
+	if err != nil {
+		t.Fatalf("TestDisconnectSlow: %v", err)
+	}
+	defer writer.Close()
+	reader := connect(t, writer, path)
+
+	msg := []byte(strings.Repeat("x", 64*1024) + "\n")
+	for i := 0; i < 100; i++ {
+		write(t, writer, msg)
+	}
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		t.Errorf("TestDisconnectSlow: the client should be disconnected: %v", err)
+	}
+}
+
+func TestMaxClients(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usock")
+	writer, err := usock.OpenConfig(usock.Config{Path: path, MaxClients: 1})
+	if err != nil {
+		t.Fatalf("TestMaxClients: %v", err)
+	}
+	defer writer.Close()
+	connect(t, writer, path)
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("TestMaxClients: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("TestMaxClients: the client should be rejected: %v", err)
+	}
+}
+
+func TestWriteAfterConnecting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usock")
+	writer, err := usock.Open(path)
+	if err != nil {
+		t.Fatalf("TestWriteAfterConnecting: %v", err)
+	}
+	defer writer.Close()
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("TestWriteAfterConnecting: %v", err)
+	}
+	defer conn.Close()
+	for deadline := time.Now().Add(time.Second); writer.Clients() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("TestWriteAfterConnecting: the client is NOT accepted")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// the client has NOT subscribed yet
+	write(t, writer, []byte("first\n"))
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || line != "first\n" {
+		t.Errorf("TestWriteAfterConnecting: got %q, %v", line, err)
+	}
+}
+
+func TestFramedNotices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usock")
+	fmtr := frame.New(frame.Config{Formatter: formatter.Func(func(record *iface.Record) []byte {
+		return []byte(record.Msg)
+	})})
+	writer, err := usock.OpenConfig(usock.Config{Path: path, QueueSize: 1, Frame: fmtr.Frame})
+	if err != nil {
+		t.Fatalf("TestFramedNotices: %v", err)
+	}
+	defer writer.Close()
+	writeMsg := func(msg string) {
+		record := &iface.Record{Msg: msg}
+		if err := writer.Write(fmtr.Format(record), record); err != nil {
+			t.Fatalf("TestFramedNotices: %v", err)
+		}
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("TestFramedNotices: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reader := frame.NewReader(conn, frame.Varint, 0)
+	if _, err := io.WriteString(conn, `{"level": "bad"}`+"\n"); err != nil {
+		t.Fatalf("TestFramedNotices: %v", err)
+	}
+	data, err := reader.ReadFrame()
+	if err != nil || !strings.HasPrefix(string(data), "<gxlog: invalid subscription: ") {
+		t.Fatalf("TestFramedNotices: unexpected frame: %q, %v", data, err)
+	}
+
+	// the client does NOT read, thus some of the messages are dropped
+	msg := strings.Repeat("x", 64*1024)
+	const count = 100
+	for i := 0; i < count; i++ {
+		writeMsg(msg)
+	}
+	frames := make(chan string, 1)
+	go func() {
+		defer close(frames)
+		for {
+			data, err := reader.ReadFrame()
+			if err != nil {
+				return
+			}
+			frames <- string(data)
+		}
+	}()
+
+	received, dropped := 0, 0
+	markerRe := regexp.MustCompile(`^<gxlog: (\d+) messages dropped>\n$`)
+	lastWritten, timeout := false, 100*time.Millisecond
+	for done := false; !done; {
+		select {
+		case data, ok := <-frames:
+			if !ok {
+				t.Fatalf("TestFramedNotices: the frames are corrupted")
+			}
+			if matches := markerRe.FindStringSubmatch(data); matches != nil {
+				n, _ := strconv.Atoi(matches[1])
+				dropped += n
+			} else if data == msg {
+				received++
+			} else if data == "last" {
+				done = true
+			} else {
+				t.Fatalf("TestFramedNotices: unexpected frame: %.32q", data)
+			}
+		case <-time.After(timeout):
+			if lastWritten {
+				t.Fatalf("TestFramedNotices: timeout")
+			}
+			writeMsg("last")
+			lastWritten, timeout = true, time.Second
+		}
+	}
+	if dropped == 0 || received+dropped != count {
+		t.Errorf("TestFramedNotices: received: %d, dropped: %d", received, dropped)
+	}
+}
+
+func TestReplayAfterConnecting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usock")
+	writer, err := usock.OpenConfig(usock.Config{Path: path, ReplaySize: 4})
+	if err != nil {
+		t.Fatalf("TestReplayAfterConnecting: %v", err)
+	}
+	defer writer.Close()
+	write(t, writer, []byte("z\n"))
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("TestReplayAfterConnecting: %v", err)
+	}
+	defer conn.Close()
+	for deadline := time.Now().Add(time.Second); writer.Clients() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("TestReplayAfterConnecting: the client is NOT accepted")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	write(t, writer, []byte("a\n"))
+	// the notice of the invalid subscription is sent after the replay
+	if _, err := io.WriteString(conn, `{"replay": 5}`+"\n"+`{"level": "bad"}`+"\n"); err != nil {
+		t.Fatalf("TestReplayAfterConnecting: %v", err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	reader := bufio.NewReader(conn)
+	for _, want := range []string{"a\n", "z\n", "<gxlog: invalid subscription: ", "b\n"} {
+		if want == "b\n" {
+			write(t, writer, []byte(want))
+		}
+		line, err := reader.ReadString('\n')
+		if err != nil || !strings.HasPrefix(line, want) {
+			t.Fatalf("TestReplayAfterConnecting: want %q, got %q, %v", want, line, err)
+		}
+	}
+}
+
+// connect returns the reader of a connected client after it receives the
+// first message.
+func connect(t *testing.T, writer *usock.Writer, path string) *bufio.Reader {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	reader := bufio.NewReader(conn)
+
+	for deadline := time.Now().Add(time.Second); ; {
+		write(t, writer, []byte("hello\n"))
+		_ = conn.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+		if _, err := reader.ReadString('\n'); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("connect: timeout")
+		}
+	}
+	// skip the extra hellos
+	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if line != "" {
+				t.Fatalf("connect: partial line: %q", line)
+			}
+			break
+		}
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return reader
+}
+
+func write(t *testing.T, writer *usock.Writer, msg []byte) {
+	if err := writer.Write(msg, record); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+func TestSubscription(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usock")
+	writer, err := usock.OpenConfig(usock.Config{Path: path, ReplaySize: 4})
+	if err != nil {
+		t.Fatalf("TestSubscription: %v", err)
+	}
+	defer writer.Close()
+
+	alice := []iface.Context{iface.String("user", "alice"), iface.Int("n", 1)}
+	bob := []iface.Context{iface.String("user", "bob")}
+	records := []*iface.Record{
+		{Level: iface.Warn, File: "/src/app/main.go", Contexts: alice, Msg: "0 evicted"},
+		{Level: iface.Info, File: "/src/app/main.go", Contexts: alice, Msg: "1 low level"},
+		{Level: iface.Warn, File: "/src/app/util.go", Contexts: alice, Msg: "2 other file"},
+		{Level: iface.Error, File: "/src/app/main.go", Contexts: bob, Msg: "3 other user"},
+		{Level: iface.Error, File: "/src/app/main.go", Contexts: alice, Msg: "4 matched"},
+	}
+	writeRecords := func() {
+		for _, record := range records {
+			if err := writer.Write([]byte(record.Msg+"\n"), record); err != nil {
+				t.Fatalf("TestSubscription: %v", err)
+			}
+		}
+	}
+	writeRecords()
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("TestSubscription: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	reader := bufio.NewReader(conn)
+	expect := func(want string) {
+		line, err := reader.ReadString('\n')
+		if err != nil || !strings.HasPrefix(line, want) {
+			t.Fatalf("TestSubscription: want %q, got %q, %v", want, line, err)
+		}
+	}
+
+	if _, err := io.WriteString(conn, `{"level": "bad"}`+"\n"); err != nil {
+		t.Fatalf("TestSubscription: %v", err)
+	}
+	expect("<gxlog: invalid subscription: ")
+	sub := `{"level": "warn", "file": "main.go", "contexts": {"user": "alice", "n": "1"}, ` +
+		`"msg": "^\\d", "replay": 10}`
+	if _, err := io.WriteString(conn, sub+"\n"); err != nil {
+		t.Fatalf("TestSubscription: %v", err)
+	}
+	expect("4 matched\n")
+
+	writeRecords()
+	expect("0 evicted\n")
+	expect("4 matched\n")
+}